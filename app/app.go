@@ -2,64 +2,203 @@
 package app
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	goversion "go/version"
 	"io"
 	"io/fs"
 	"net/http"
-	"os"
-	"path/filepath"
+	"runtime"
 	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"go-simpler.org/goversion/fsx"
 )
 
+// App implements the goversion commands.
+//
+// An App never modifies $PATH, so it can be embedded in a long-running process.
+// The read-only commands (e.g. [App.List], [App.Info] and [App.Status]) may run concurrently,
+// as long as the injected dependencies are safe for concurrent use;
+// the others modify $GOBIN and the state files, so they must not overlap.
+// The post-use hook gets GOVERSION_NEW in its own environment (see [App.RunCmdEnv]),
+// so an App doesn't affect the environment of the process at all.
 type App struct {
-	GoBin, SDK fsx.FS
-	Output     io.Writer
-	RunCmd     func(ctx context.Context, name string, args ...string) error
-	RunCmdOut  func(ctx context.Context, name string, args ...string) (string, error)
-	Requester  interface {
+	GoBin, SDK   fsx.FS
+	State        fsx.FS
+	Input        io.Reader
+	Output       io.Writer
+	Errors       io.Writer // optional, for warnings.
+	Env          Env       // optional, [OSEnv] by default.
+	RunCmd       func(ctx context.Context, name string, args ...string) error
+	RunCmdOut    func(ctx context.Context, name string, args ...string) (string, error)
+	LookPath     func(file, path string) (string, error) // optional, finds the executable in the $PATH value (see [LookPath]).
+	Now          func() time.Time
+	Timeout      time.Duration // for network requests; no timeout if zero.
+	HTMLFallback bool          // scrape the go.dev/dl HTML page if the JSON endpoint fails or returns no versions.
+	CopyBinary   bool          // copy go<version> to $GOBIN/go instead of symlinking it (e.g. if symlinks are unsupported).
+	OnEvent      func(Event)   // optional, for embedding UIs; called at each milestone of Use and Remove.
+	Version      string        // optional, the goversion version sent in the User-Agent header ("dev" by default).
+
+	// ConfirmNetwork is optional; if set, it's called with a description of each network access
+	// (go.dev requests, `go install` and SDK downloads) before it's made, and returning false aborts
+	// the command with [ErrNetworkDeclined].
+	ConfirmNetwork func(description string) bool
+	// Sleep is optional; it's called to wait between the installation retries of [UseOptions.Wait]
+	// and should return the context's error if it's done earlier. A timer is used if it's nil.
+	Sleep func(ctx context.Context, d time.Duration) error
+	// RunCmdEnv is optional; it's like RunCmd, but adds the key=value variables to the environment
+	// of the command (e.g. GOVERSION_NEW for the post-use hook). The hook is skipped if it's nil.
+	RunCmdEnv func(ctx context.Context, env []string, name string, args ...string) error
+	// Requester is optional; if nil, network requests fail with [ErrNetworkDisabled].
+	Requester interface {
 		Do(*http.Request) (*http.Response, error)
 	}
+
+	stateWarned bool // whether the unwritable state directory has been reported (see [App.writeState]).
+}
+
+// Event is a milestone of a long-running operation, e.g. for rendering progress in a GUI.
+type Event struct {
+	Type    EventType
+	Version string
+	Message string // a human-readable description, e.g. "downloading go1.21.6 SDK".
+}
+
+// EventType is the type of an [Event].
+type EventType string
+
+const (
+	EventStart    EventType = "start"    // the operation has started.
+	EventDownload EventType = "download" // a binary or an SDK is being downloaded.
+	EventSwitch   EventType = "switch"   // $GOBIN/go now points to the version.
+	EventDone     EventType = "done"     // the operation has succeeded.
+)
+
+// emit calls [App.OnEvent], if set.
+func (a *App) emit(typ EventType, version, message string) {
+	if a.OnEvent != nil {
+		a.OnEvent(Event{Type: typ, Version: version, Message: message})
+	}
+}
+
+// ErrNotInstalled is returned by [App.Use] with [UseOptions.FailIfMissing] if the version is not installed,
+// and by [App.Remove] and [App.Reinstall].
+var ErrNotInstalled = errors.New("version is not installed")
+
+// UseOptions configures the behavior of [App.Use].
+type UseOptions struct {
+	FailIfMissing bool // return [ErrNotInstalled] instead of installing the version.
+	DownloadOnly  bool // install the version (both binary and SDK) but don't switch to it.
+	ReplaceMinor  bool // after switching, remove the other installed patches of the same minor version.
+	PrintOnly     bool // only print the version the spec (e.g. 1.21@latest) resolves to, without installing or switching.
+
+	// InstallFlags are passed to `go install golang.org/dl/go<version>@latest` before the module path (e.g. -x).
+	// They don't affect the SDK download.
+	InstallFlags []string
+
+	// Wait is how long to keep retrying the installation of the go<version> binary,
+	// e.g. if a freshly tagged version is already on go.dev but not yet on golang.org/dl.
+	Wait time.Duration
 }
 
-func (a *App) Use(ctx context.Context, version string) error {
+func (a *App) Use(ctx context.Context, version string, opts UseOptions) error {
+	// the version may come from e.g. $(cat .go-version) with a trailing newline.
+	version = strings.TrimSpace(version)
+
 	local, err := a.localVersions(ctx)
 	if err != nil {
 		return err
 	}
 
-	if version == "main" {
+	switch version {
+	case "main":
 		version = local.main
+	case "-":
+		if version, err = a.previousVersion(); err != nil {
+			return err
+		}
+	}
+
+	var tipRef string
+	if base, suffix, ok := strings.Cut(version, "@"); ok {
+		switch {
+		case base == "tip" && suffix != "":
+			version, tipRef = base, suffix
+		default:
+			if version, err = a.resolveSuffix(ctx, local, base, suffix); err != nil {
+				return err
+			}
+		}
 	}
 
 	if !isValid(version) {
 		return fmt.Errorf("malformed version %q", version)
 	}
 
+	if opts.PrintOnly {
+		fmt.Fprintln(a.Output, version)
+		return nil
+	}
+
+	if tipRef != "" {
+		if opts.FailIfMissing && (!slices.Contains(local.list, version) || !a.downloaded(version)) {
+			return fmt.Errorf("%s: %w", version, ErrNotInstalled)
+		}
+		if err := a.buildTip(ctx, local, tipRef, opts.InstallFlags); err != nil {
+			return err
+		}
+	}
+
+	a.emit(EventStart, version, "switching")
+
 	switch version {
 	case local.current:
+		if version == local.main && local.linked {
+			// the symlink redundantly points to the main version (e.g. created manually),
+			// so remove it to normalize the state.
+			if err := a.GoBin.Remove("go" + exe()); err != nil {
+				return err
+			}
+		}
 		fmt.Fprintf(a.Output, "%s is already in use\n", version)
+		if opts.ReplaceMinor {
+			a.replaceMinor(local, version)
+		}
+		a.emit(EventDone, version, "already in use")
 		return nil
 	case local.main:
+		if opts.DownloadOnly {
+			fmt.Fprintf(a.Output, "%s (main) is ready\n", version)
+			a.emit(EventDone, version, "ready")
+			return nil
+		}
 		if err := a.GoBin.Remove("go" + exe()); err != nil {
 			return err
 		}
-		fmt.Fprintf(a.Output, "Switched to %s (main)\n", version)
+		a.recordSwitch(local.current, version)
+		a.emit(EventSwitch, version, "switched from "+local.current)
+		fmt.Fprintf(a.Output, "Switched to %s (main, was %s)\n", version, local.current)
+		a.runPostUseHook(ctx, version)
+		a.emit(EventDone, version, "switched")
 		return nil
 	}
 
+	if opts.FailIfMissing && (!slices.Contains(local.list, version) || !a.downloaded(version)) {
+		return fmt.Errorf("%s: %w", version, ErrNotInstalled)
+	}
+
 	initial := false
 	if !slices.Contains(local.list, version) {
 		initial = true
 		fmt.Fprintf(a.Output, "%s is not installed. Looking for it on go.dev ...\n", version)
-		url := fmt.Sprintf("golang.org/dl/go%s@latest", version)
-		if err := a.RunCmd(ctx, "go", "install", url); err != nil {
+		if err := a.installBinaryWait(ctx, version, opts); err != nil {
 			return err
 		}
 	}
@@ -71,58 +210,422 @@ func (a *App) Use(ctx context.Context, version string) error {
 			// this message doesn't make sense during initial installation.
 			fmt.Fprintf(a.Output, "%s SDK is missing. Starting download ...\n", version)
 		}
-		if err := a.RunCmd(ctx, "go"+version, "download"); err != nil {
+		if err := a.downloadSDK(ctx, version); err != nil {
 			return err
 		}
 	}
 
+	if opts.DownloadOnly {
+		fmt.Fprintf(a.Output, "%s is ready\n", version)
+		a.emit(EventDone, version, "ready")
+		return nil
+	}
+
 	if err := a.GoBin.Remove("go" + exe()); err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return err
 	}
-	if err := a.GoBin.Symlink("go"+version+exe(), "go"+exe()); err != nil {
+	if err := a.linkGo(version); err != nil {
 		return err
 	}
+	a.recordSwitch(local.current, version)
+	a.emit(EventSwitch, version, "switched from "+local.current)
+
+	was := local.current
+	if was == local.main {
+		was += ", main"
+	}
+	fmt.Fprintf(a.Output, "Switched to %s (was %s)\n", version, was)
+	if goroot := a.shadowingGOROOT(local, version); goroot != "" {
+		a.warnf("GOROOT=%s shadows the %s SDK, so the switch has no effect; run `unset GOROOT` to fix it", goroot, version)
+	}
+	a.runPostUseHook(ctx, version)
+	if opts.ReplaceMinor {
+		a.replaceMinor(local, version)
+	}
+	a.emit(EventDone, version, "switched")
+	return nil
+}
+
+// replaceMinor removes the installed patches of the version's minor version other than the version itself,
+// except for the main and pinned ones. It runs after a successful switch, so failures are only reported.
+func (a *App) replaceMinor(local *local, version string) {
+	pins, err := a.readPins()
+	if err != nil {
+		a.warnf("reading pins: %v", err)
+		return
+	}
+	for _, v := range local.list {
+		if v == version || v == local.main || v == "tip" || minorVersion(v) != minorVersion(version) || slices.Contains(pins, v) {
+			continue
+		}
+		if err := a.removeInstalled(v); err != nil {
+			a.warnf("removing %s: %v", v, err)
+		}
+	}
+}
+
+// linkGo makes $GOBIN/go run the go<version> binary,
+// either by symlinking it or by copying it (see [App.CopyBinary]).
+func (a *App) linkGo(version string) error {
+	if a.CopyBinary {
+		data, err := fs.ReadFile(a.GoBin, "go"+version+exe())
+		if err != nil {
+			return err
+		}
+		return a.GoBin.WriteFile("go"+exe(), data, 0o755)
+	}
 
-	fmt.Fprintf(a.Output, "Switched to %s\n", version)
+	if err := a.GoBin.Symlink("go"+version+exe(), "go"+exe()); err != nil {
+		return err
+	}
+	// some filesystems (e.g. network mounts) may resolve a successfully created symlink oddly.
+	link, err := a.GoBin.Readlink("go" + exe())
+	if err != nil {
+		return fmt.Errorf("verifying $GOBIN/go%s: %w", exe(), err)
+	}
+	if binaryVersion(link) != version {
+		return fmt.Errorf("$GOBIN/go%s points to %q instead of go%s%s after switching", exe(), link, version, exe())
+	}
 	return nil
 }
 
-func (a *App) List(ctx context.Context, printAll bool, printOnly string) error {
+// copiedVersion returns the version of the go<version> binary that $GOBIN/go is a copy of,
+// or an empty string if it's not a copy of any of them.
+func (a *App) copiedVersion(versions []string) (string, error) {
+	data, err := fs.ReadFile(a.GoBin, "go"+exe())
+	if err != nil {
+		return "", err
+	}
+	for _, version := range versions {
+		other, err := fs.ReadFile(a.GoBin, "go"+version+exe())
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			continue // e.g. the main version.
+		case err != nil:
+			return "", err
+		case bytes.Equal(data, other):
+			return version, nil
+		}
+	}
+	return "", nil
+}
+
+// ParseVersionFile returns the version from the contents of a version file (e.g. for `use -from-file`):
+// the first line that is neither empty nor a # comment, with surrounding whitespace trimmed.
+func ParseVersionFile(data []byte) (string, error) {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// the rest of a <version>@<suffix> spec is validated by [App.Use].
+		if base, _, _ := strings.Cut(line, "@"); !isValid(base) && base != "main" {
+			return "", fmt.Errorf("malformed version %q", line)
+		}
+		return line, nil
+	}
+	return "", errors.New("no version has been found")
+}
+
+// ParseGoMod returns the version from the contents of a go.mod file (e.g. for `use mod`):
+// the toolchain directive if present, since it's the version the go command actually uses,
+// or the go directive otherwise. A toolchain with a custom suffix (e.g. go1.21.6-custom) is reported without it.
+// Since Go 1.21, a bare go directive (e.g. go 1.22) means the first release of the language version (1.22.0).
+func ParseGoMod(data []byte) (string, error) {
+	var goVersion, toolchain string
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "go":
+			goVersion = fields[1]
+			if v := "go" + goVersion; goversion.Lang(v) == v && goversion.Compare(v, "go1.21") >= 0 {
+				goVersion += ".0"
+			}
+		case "toolchain":
+			if fields[1] != "default" {
+				toolchain = strings.TrimPrefix(fields[1], "go")
+				if i := strings.IndexAny(toolchain, "+-"); i > 0 {
+					toolchain = toolchain[:i]
+				}
+			}
+		}
+	}
+
+	version := cmp.Or(toolchain, goVersion)
+	if version == "" {
+		return "", errors.New("neither toolchain nor go directive has been found")
+	}
+	if !isValid(version) {
+		return "", fmt.Errorf("malformed version %q", version)
+	}
+	return version, nil
+}
+
+// ListOptions configures the output of [App.List].
+type ListOptions struct {
+	All        bool   // print also available versions from go.dev.
+	Only       string // print only versions starting with the prefix (or only the latest patches if "latest").
+	Since      string // print only versions newer than this one.
+	Until      string // print only versions older than or equal to this one.
+	ActiveOnly bool   // print only the current version.
+	Minor      bool   // print only the latest patch for each minor version (the same as Only "latest").
+	RequireSDK bool   // return [ErrMissingSDK] if the SDK of the current version is missing.
+	Prerelease string // print only prereleases of the kind: beta or rc.
+	SDKPath    bool   // print also the SDK path of installed versions.
+	Outdated   bool   // mark installed versions that have a newer patch available.
+	FromStdin  bool   // read available versions from [App.Input] instead of go.dev (implies All).
+	Porcelain  bool   // print a stable tab-separated output for scripts.
+	JSON       bool   // print the output in the JSON format.
+	Sizes      bool   // with JSON, include the SDK size of installed versions (walks their SDK directories).
+	OS, Arch   string // print only versions that ship a file for the platform (implies All).
+	Kind       string // the kind of the file for OS and Arch: archive (default), installer or source.
+
+	PreferInstalled bool // print installed versions (including main) before the ones not installed.
+	Grouped         bool // print versions under a header per minor version (text output only).
+	NoMain          bool // hide the main version, unless its go<version> binary is also installed.
+	ActiveFirst     bool // print the current version first (also ls.active_first in the config).
+	Duplicates      bool // print only installed versions whose minor version has other patches installed.
+	Security        bool // mark the latest patches of the supported minor versions as recommended (implies All).
+
+	// Compare is the version to mark the others as newer, same or older than.
+	Compare string
+}
+
+// ErrMissingSDK is returned by [App.List] with [ListOptions.RequireSDK] if the SDK of the current version is missing.
+var ErrMissingSDK = errors.New("SDK is missing")
+
+func (a *App) List(ctx context.Context, opts ListOptions) error {
+	for _, bound := range []string{opts.Since, opts.Until, opts.Compare} {
+		if bound != "" && !isValid(bound) {
+			return fmt.Errorf("malformed version %q", bound)
+		}
+	}
+	if opts.Sizes && !opts.JSON {
+		return errors.New("-sizes can only be used with -json")
+	}
+	if opts.Prerelease != "" && opts.Prerelease != "beta" && opts.Prerelease != "rc" {
+		return fmt.Errorf("unknown prerelease kind %q (supported: beta, rc)", opts.Prerelease)
+	}
+
 	local, err := a.localVersions(ctx)
 	if err != nil {
 		return err
 	}
 
-	versions := local.list
-	if printAll {
-		if versions, err = a.remoteVersions(ctx); err != nil {
+	// the missing parts default to the current platform and archives.
+	var filter fileFilter
+	if opts.OS != "" || opts.Arch != "" || opts.Kind != "" {
+		filter = fileFilter{
+			os:   cmp.Or(opts.OS, runtime.GOOS),
+			arch: cmp.Or(opts.Arch, runtime.GOARCH),
+			kind: cmp.Or(opts.Kind, "archive"),
+		}
+		if !slices.Contains([]string{"archive", "installer", "source"}, filter.kind) {
+			return fmt.Errorf("unknown file kind %q (supported: archive, installer, source)", opts.Kind)
+		}
+	}
+
+	var remote []string
+	switch {
+	case opts.FromStdin:
+		if remote, err = a.decodeVersions(a.Input, filter); err != nil {
+			return fmt.Errorf("reading versions from stdin: %w", err)
+		}
+	case opts.All || opts.Outdated || opts.Security || filter != (fileFilter{}):
+		if remote, err = a.remoteVersions(ctx, filter); err != nil {
 			return err
 		}
 	}
 
+	versions := local.list
+	if remote != nil && !opts.Outdated {
+		versions = remote
+	}
+
+	// installed version -> the latest patch available for it.
+	updates := make(map[string]string)
+	behind := make(map[string]int) // the number of newer patches.
+	if opts.Outdated {
+		for _, version := range local.list {
+			if patch := latestPatch(remote, version); patch != "" && versionNewer(patch, version) {
+				updates[version] = patch
+				behind[version] = patchesBehind(remote, version)
+			}
+		}
+	}
+
+	// the output is the same either way; the error only signals the result (e.g. to fail a CI job).
+	var result error
+	switch {
+	case opts.RequireSDK && local.current != local.main && !a.downloaded(local.current):
+		result = ErrMissingSDK
+	case len(updates) > 0:
+		result = ErrUpdateAvailable
+	}
+
+	printOnly := opts.Only
 	if printOnly == "latest" {
 		printOnly = ""
+		opts.Minor = true
+	}
+	if opts.Minor {
 		versions = latestPatches(versions)
 	}
 
+	// the latest patches of the supported minor versions, which usually include security fixes.
+	var recommended []string
+	if opts.Security {
+		recommended = supportedPatches(remote)
+	}
+
+	// minor version -> the number of installed patches.
+	patches := make(map[string]int)
+	for _, version := range local.list {
+		if version != "tip" {
+			patches[minorVersion(version)]++
+		}
+	}
+
+	var filtered []string
+	for _, version := range versions {
+		if !hasVersionPrefix(version, printOnly) {
+			continue
+		}
+		if opts.Since != "" && !versionNewer(version, opts.Since) {
+			continue
+		}
+		if opts.Until != "" && versionNewer(version, opts.Until) {
+			continue
+		}
+		if opts.ActiveOnly && version != local.current {
+			continue
+		}
+		if opts.NoMain && version == local.main && !a.managed(version) {
+			continue
+		}
+		if opts.Duplicates && (!slices.Contains(local.list, version) || version == "tip" || patches[minorVersion(version)] < 2) {
+			continue
+		}
+		// stable releases and tip have no tail.
+		if _, _, tail := parseVersion(version); opts.Prerelease != "" && !strings.HasPrefix(tail, opts.Prerelease) {
+			continue
+		}
+		filtered = append(filtered, version)
+	}
+	versions = filtered
+
+	if opts.PreferInstalled {
+		// both groups stay sorted from newest to oldest.
+		var installed, notInstalled []string
+		for _, version := range versions {
+			if slices.Contains(local.list, version) {
+				installed = append(installed, version)
+			} else {
+				notInstalled = append(notInstalled, version)
+			}
+		}
+		versions = append(installed, notInstalled...)
+	}
+
+	if opts.ActiveFirst || a.configBool("ls.active_first") {
+		if i := slices.Index(versions, local.current); i > 0 {
+			versions = slices.Concat([]string{local.current}, versions[:i], versions[i+1:])
+		}
+	}
+
+	if opts.Porcelain {
+		for _, version := range versions {
+			fmt.Fprintf(a.Output, "%s\t%s\n", version, a.status(local, version))
+		}
+		return result
+	}
+
+	if opts.JSON {
+		out := listJSON{Schema: JSONSchema, Versions: []versionJSON{}}
+		for _, version := range versions {
+			v := versionJSON{
+				Version: version,
+				Status:  a.status(local, version),
+				Channel: channel(version),
+				Current: version == local.current,
+				SDKPath: a.sdkPath(local, version),
+				Update:  updates[version],
+				Behind:  behind[version],
+			}
+			if opts.Compare != "" {
+				v.Compare = compareVersion(version, opts.Compare)
+			}
+			// the SDK is walked only if requested, and only for installed versions.
+			if opts.Sizes && v.SDKPath != "" && a.downloaded(version) {
+				size, err := a.sdkSize(version)
+				if err != nil {
+					return err
+				}
+				v.Size = size
+			}
+			out.Versions = append(out.Versions, v)
+		}
+		if err := a.printJSON(out); err != nil {
+			return err
+		}
+		return result
+	}
+
 	var maxLen int
 	for _, version := range versions {
 		maxLen = max(maxLen, len(version))
 	}
 
-	for _, version := range versions {
-		if !strings.HasPrefix(version, printOnly) {
-			continue
+	// the first column contains the version and optionally its SDK path.
+	columns := make([]string, len(versions))
+	var maxColumnLen int
+	for i, version := range versions {
+		columns[i] = version
+		if path := a.sdkPath(local, version); opts.SDKPath && path != "" {
+			columns[i] = fmt.Sprintf("%-*s %s", maxLen, version, path)
 		}
+		maxColumnLen = max(maxColumnLen, len(columns[i]))
+	}
 
-		var extra string
+	var missingSDK bool
+	for i, version := range versions {
+		var notes []string
 		switch {
 		case version == local.main:
-			extra = " (main)"
+			notes = append(notes, "main")
 		case !slices.Contains(local.list, version):
-			extra = " (not installed)"
+			notes = append(notes, "not installed")
 		case !a.downloaded(version):
-			extra = " (missing SDK)"
+			notes = append(notes, "missing SDK")
+			missingSDK = true
+		}
+		if patch, ok := updates[version]; ok {
+			notes = append(notes, patch+" available")
+			if n := behind[version]; n == 1 {
+				notes = append(notes, "1 patch behind")
+			} else {
+				notes = append(notes, fmt.Sprintf("%d patches behind", n))
+			}
+		}
+		if slices.Contains(recommended, version) {
+			notes = append(notes, "latest — recommended")
+		}
+		if opts.Compare != "" {
+			notes = append(notes, compareVersion(version, opts.Compare))
+		}
+		if opts.Duplicates && version != local.main && a.downloaded(version) {
+			if size, err := a.sdkSize(version); err == nil {
+				notes = append(notes, formatSize(size))
+			}
+		}
+
+		var extra string
+		if len(notes) > 0 {
+			extra = " (" + strings.Join(notes, ", ") + ")"
 		}
 
 		prefix := " "
@@ -130,13 +633,40 @@ func (a *App) List(ctx context.Context, printAll bool, printOnly string) error {
 			prefix = "*"
 		}
 
-		fmt.Fprintf(a.Output, "%s %-*s%s\n", prefix, maxLen, version, extra)
+		if opts.Grouped {
+			if minor := minorVersion(version); i == 0 || minor != minorVersion(versions[i-1]) {
+				fmt.Fprintf(a.Output, "%s:\n", minor)
+			}
+			prefix = "  " + prefix
+		}
+
+		fmt.Fprintf(a.Output, "%s %-*s%s\n", prefix, maxColumnLen, columns[i], extra)
 	}
 
-	return nil
+	if opts.Duplicates {
+		if len(versions) == 0 {
+			fmt.Fprintf(a.Output, "No minor versions have multiple patches installed\n")
+		} else {
+			fmt.Fprintf(a.Output, "\nThe older patches can be removed with `goversion rm <version>`\n")
+		}
+	}
+
+	// golang.org/dl always downloads SDKs to $HOME/sdk;
+	// if none are found there, the layout is likely different from what we expect.
+	if missingSDK && !a.anySDK() {
+		a.warnf("no SDKs were found; goversion expects them in $HOME/sdk")
+	}
+
+	return result
+}
+
+// InfoOptions configures the output of [App.Info].
+type InfoOptions struct {
+	Outdated bool // check whether a newer patch is available on go.dev.
+	JSON     bool // print the output in the JSON format.
 }
 
-func (a *App) Remove(ctx context.Context, version string) error {
+func (a *App) Info(ctx context.Context, version string, opts InfoOptions) error {
 	local, err := a.localVersions(ctx)
 	if err != nil {
 		return err
@@ -150,133 +680,977 @@ func (a *App) Remove(ctx context.Context, version string) error {
 		return fmt.Errorf("malformed version %q", version)
 	}
 
-	if !slices.Contains(local.list, version) {
-		return fmt.Errorf("%s is not installed", version)
+	var sdkSize int64
+	sdkPath := a.sdkPath(local, version)
+	if sdkPath != "" && a.downloaded(version) {
+		if sdkSize, err = a.sdkSize(version); err != nil {
+			return err
+		}
 	}
 
-	switch version {
-	case local.main:
-		return fmt.Errorf("unable to remove %s (main)", version)
-	case local.current:
-		if err := a.GoBin.Remove("go" + exe()); err != nil {
+	var update string
+	if opts.Outdated {
+		remote, err := a.remoteVersions(ctx, fileFilter{})
+		if err != nil {
 			return err
 		}
-		fmt.Fprintf(a.Output, "Switched to %s (main)\n", local.main)
+		if patch := latestPatch(remote, version); patch != "" && versionNewer(patch, version) {
+			update = patch
+		}
 	}
 
-	if err := a.GoBin.Remove("go" + version + exe()); err != nil {
-		return err
+	status := a.status(local, version)
+
+	var ref string
+	if version == "tip" && a.downloaded(version) {
+		ref = a.tipRef()
 	}
-	if err := a.SDK.RemoveAll("go" + version); err != nil {
-		return err
+
+	if opts.JSON {
+		return a.printJSON(infoJSON{
+			Schema: JSONSchema,
+			versionJSON: versionJSON{
+				Version: version,
+				Status:  status,
+				Channel: channel(version),
+				Current: version == local.current,
+				SDKPath: sdkPath,
+				Update:  update,
+			},
+			Installed: slices.Contains(local.list, version),
+			SDKSize:   sdkSize,
+			Ref:       ref,
+		})
+	}
+
+	fmt.Fprintf(a.Output, "Version:  %s\n", version)
+	fmt.Fprintf(a.Output, "Status:   %s\n", status)
+	if sdkPath != "" {
+		fmt.Fprintf(a.Output, "SDK path: %s\n", sdkPath)
+	}
+	if sdkSize > 0 {
+		fmt.Fprintf(a.Output, "SDK size: %s\n", formatSize(sdkSize))
+	}
+	if ref != "" {
+		fmt.Fprintf(a.Output, "Ref:      %s\n", ref)
+	}
+	if opts.Outdated {
+		if update == "" {
+			update = "none"
+		}
+		fmt.Fprintf(a.Output, "Update:   %s\n", update)
 	}
 
-	fmt.Fprintf(a.Output, "Removed %s\n", version)
 	return nil
 }
 
-func (a *App) downloaded(version string) bool {
-	// from https://github.com/golang/dl/blob/master/internal/version/version.go:
-	// .unpacked-success is a sentinel zero-byte file to indicate that the Go version was downloaded and unpacked successfully.
-	name := "go" + version + "/.unpacked-success"
-	if version == "tip" {
-		name = "gotip/bin/go" // https://github.com/golang/dl/blob/master/internal/version/gotip.go#L45
-	}
-	_, err := fs.Stat(a.SDK, name)
-	return err == nil
-}
+// RemoveOptions configures the behavior of [App.Remove].
+type RemoveOptions struct {
+	Force    bool // remove the version even if it is pinned.
+	NoSwitch bool // return an error instead of switching to main if the version is in use.
 
-type local struct {
-	main    string
-	current string
-	list    []string // includes both main and current.
+	// the options of [App.RemoveUnused] and [App.RemoveOldPatches].
+	OlderThan time.Duration // remove versions whose SDK hasn't been modified for this long.
+	Keep      int           // the number of the newest patches to keep per minor version.
+	DryRun    bool          // only print the versions that would be removed.
 }
 
-func (a *App) localVersions(ctx context.Context) (*local, error) {
-	currPath := os.Getenv("PATH")
-	defer os.Setenv("PATH", currPath)
-
-	// temporarily remove $GOBIN from $PATH to force [exec.Command] to use the main go binary.
-	tempPath := cutFromPath(currPath, os.Getenv("GOBIN"))
-	os.Setenv("PATH", tempPath)
+func (a *App) Remove(ctx context.Context, version string, opts RemoveOptions) error {
+	version = strings.TrimSpace(version) // see [App.Use].
 
-	output, err := a.RunCmdOut(ctx, "go", "version")
+	local, err := a.localVersions(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var main string
-	if _, err := fmt.Fscanf(strings.NewReader(output), "go version go%s", &main); err != nil {
-		return nil, fmt.Errorf("unexpected format %q", output)
+	if version == "main" {
+		version = local.main
 	}
 
-	var current string
-	switch link, err := a.GoBin.Readlink("go" + exe()); {
-	case errors.Is(err, fs.ErrNotExist):
-		current = main
-	case err == nil:
-		current = strings.TrimPrefix(filepath.Base(link), "go")
-		current = strings.TrimSuffix(current, ".exe")
-	default:
-		return nil, err
+	if !isValid(version) {
+		return fmt.Errorf("malformed version %q", version)
 	}
 
-	entries, err := fs.ReadDir(a.GoBin, ".")
-	if err != nil {
-		return nil, err
+	if !slices.Contains(local.list, version) {
+		return fmt.Errorf("%s: %w", version, ErrNotInstalled)
 	}
 
-	list := []string{main}
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+	if version == local.main {
+		return fmt.Errorf("unable to remove %s (main)", version)
+	}
+
+	if !opts.Force {
+		pins, err := a.readPins()
+		if err != nil {
+			return err
 		}
-		version := strings.TrimPrefix(entry.Name(), "go")
-		version = strings.TrimSuffix(version, ".exe")
-		if isValid(version) {
-			list = append(list, version)
+		if slices.Contains(pins, version) {
+			return fmt.Errorf("%s is pinned; unpin it or use -force", version)
 		}
 	}
 
-	sort.Slice(list, func(i, j int) bool {
-		return versionLess(list[i], list[j])
-	})
+	a.emit(EventStart, version, "removing")
 
-	return &local{
-		main:    main,
-		current: current,
-		list:    list,
-	}, nil
-}
+	if version == local.current {
+		if opts.NoSwitch {
+			return fmt.Errorf("%s is in use; switch to another version first", version)
+		}
+		if err := a.GoBin.Remove("go" + exe()); err != nil {
+			return err
+		}
+		a.emit(EventSwitch, local.main, "switched from "+version)
+		fmt.Fprintf(a.Output, "Switched to %s (main)\n", local.main)
+	}
 
-func (a *App) remoteVersions(ctx context.Context) ([]string, error) {
-	// sorted by version, from newest to oldest.
-	const url = "https://go.dev/dl/?mode=json&include=all"
+	return a.removeInstalled(version)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+// RemoveUnused removes installed versions whose SDK hasn't been modified for [RemoveOptions.OlderThan],
+// which is a proxy for being unused. The main, current and pinned versions are always kept.
+func (a *App) RemoveUnused(ctx context.Context, opts RemoveOptions) error {
+	local, err := a.localVersions(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	resp, err := a.Requester.Do(req)
+	pins, err := a.readPins()
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	cutoff := a.now().Add(-opts.OlderThan)
+
+	var removed int
+	for _, version := range local.list {
+		if version == local.main || version == local.current || slices.Contains(pins, version) {
+			continue
+		}
+		info, err := fs.Stat(a.SDK, "go"+version)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // there is no SDK to tell whether it's used.
+		}
+		if err != nil {
+			return err
+		}
+		if !info.ModTime().Before(cutoff) {
+			continue
+		}
+
+		removed++
+		if opts.DryRun {
+			fmt.Fprintf(a.Output, "Would remove %s (SDK last modified %s)\n", version, info.ModTime().Format(time.DateOnly))
+			continue
+		}
+		if err := a.removeInstalled(version); err != nil {
+			return err
+		}
+	}
+
+	if removed == 0 {
+		fmt.Fprintf(a.Output, "No unused versions have been found\n")
+	}
+	return nil
+}
+
+// RemoveOldPatches removes all but the newest [RemoveOptions.Keep] installed patches of the minor version
+// (e.g. 1.21), or of each minor version if it's empty. The main, current and pinned versions are always kept.
+func (a *App) RemoveOldPatches(ctx context.Context, minor string, opts RemoveOptions) error {
+	minor = strings.TrimSpace(minor) // see [App.Use].
+	if minor != "" && (!isValid(minor) || minor == "tip" || minorVersion(minor) != minor) {
+		return fmt.Errorf("malformed minor version %q", minor)
+	}
+	if opts.Keep <= 0 {
+		return fmt.Errorf("the number of patches to keep must be positive, got %d", opts.Keep)
+	}
+
+	local, err := a.localVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	pins, err := a.readPins()
+	if err != nil {
+		return err
+	}
+
+	// the list is sorted from newest to oldest, so the first patches of each minor version are kept.
+	kept := make(map[string]int)
+	var removed int
+	for _, version := range local.list {
+		if version == "tip" || (minor != "" && minorVersion(version) != minor) {
+			continue
+		}
+		if kept[minorVersion(version)] < opts.Keep {
+			kept[minorVersion(version)]++
+			continue
+		}
+		if version == local.main || version == local.current || slices.Contains(pins, version) {
+			continue
+		}
+
+		removed++
+		if opts.DryRun {
+			fmt.Fprintf(a.Output, "Would remove %s\n", version)
+			continue
+		}
+		if err := a.removeInstalled(version); err != nil {
+			return err
+		}
+	}
+
+	if removed == 0 {
+		fmt.Fprintf(a.Output, "No older patches have been found\n")
+	}
+	return nil
+}
+
+// removeInstalled removes the binary and the SDK of the installed version, which must not be in use.
+func (a *App) removeInstalled(version string) error {
+	// the SDK may have already been deleted manually; RemoveAll still cleans up a partial download.
+	downloaded := a.downloaded(version)
+
+	// unlike a release SDK, gotip is a full source checkout with build artifacts,
+	// so the freed space is worth reporting. Note that both the binary and the directory are named gotip.
+	var freed string
+	if version == "tip" {
+		if size, err := a.sdkSize(version); err == nil && size > 0 {
+			freed = fmt.Sprintf(" (freed %s)", formatSize(size))
+		}
+	}
+
+	if err := a.GoBin.Remove("go" + version + exe()); err != nil {
+		return err
+	}
+	if err := a.SDK.RemoveAll("go" + version); err != nil {
+		return err
+	}
+
+	// forget the removed version if it was the previous one.
+	a.forgetPrevious(version)
+	if version == "tip" {
+		a.recordTipRef("")
+	}
+
+	if !downloaded {
+		fmt.Fprintf(a.Output, "Removed %s (binary only; SDK was already absent)\n", version)
+	} else {
+		fmt.Fprintf(a.Output, "Removed %s%s\n", version, freed)
+	}
+	a.emit(EventDone, version, "removed")
+	return nil
+}
+
+func (a *App) Reinstall(ctx context.Context, version string) error {
+	local, err := a.localVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	if version == "main" {
+		version = local.main
+	}
+
+	if !isValid(version) {
+		return fmt.Errorf("malformed version %q", version)
+	}
+
+	if !slices.Contains(local.list, version) {
+		return fmt.Errorf("%s: %w", version, ErrNotInstalled)
+	}
+
+	if version == local.main {
+		return fmt.Errorf("unable to reinstall %s (main)", version)
+	}
+
+	// the go binary must keep working while the SDK is being downloaded,
+	// so we temporarily switch to the main version.
+	active := version == local.current
+	if active {
+		if err := a.GoBin.Remove("go" + exe()); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.Output, "Temporarily switched to %s (main)\n", local.main)
+	}
+
+	// the user may be left without the SDK (and on the main version), so a failure must name the version.
+	failed := func(err error) error {
+		if active {
+			return fmt.Errorf("reinstall of %s failed; switched to %s (main): %w", version, local.main, err)
+		}
+		return fmt.Errorf("reinstall of %s failed: %w", version, err)
+	}
+
+	if !a.executable("go" + version + exe()) {
+		fmt.Fprintf(a.Output, "go%s binary is broken. Looking for it on go.dev ...\n", version)
+		if err := a.installBinary(ctx, version); err != nil {
+			return failed(err)
+		}
+	}
+
+	if err := a.SDK.RemoveAll("go" + version); err != nil {
+		return failed(err)
+	}
+
+	fmt.Fprintf(a.Output, "Removed %s SDK. Starting download ...\n", version)
+	if err := a.downloadSDK(ctx, version); err != nil {
+		return failed(err)
+	}
+
+	if active {
+		if err := a.linkGo(version); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.Output, "Switched back to %s\n", version)
+	}
+
+	fmt.Fprintf(a.Output, "Reinstalled %s\n", version)
+	return nil
+}
+
+func (a *App) Pin(version string) error {
+	if !isValid(version) {
+		return fmt.Errorf("malformed version %q", version)
+	}
+
+	pins, err := a.readPins()
+	if err != nil {
+		return err
+	}
+
+	if slices.Contains(pins, version) {
+		fmt.Fprintf(a.Output, "%s is already pinned\n", version)
+		return nil
+	}
+
+	if err := a.writePins(append(pins, version)); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.Output, "Pinned %s\n", version)
+	return nil
+}
+
+func (a *App) Unpin(version string) error {
+	pins, err := a.readPins()
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(pins, version) {
+		return fmt.Errorf("%s is not pinned", version)
+	}
+
+	pins = slices.DeleteFunc(pins, func(v string) bool { return v == version })
+	if err := a.writePins(pins); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.Output, "Unpinned %s\n", version)
+	return nil
+}
+
+func (a *App) ListPins() error {
+	pins, err := a.readPins()
+	if err != nil {
+		return err
+	}
+
+	for _, pin := range pins {
+		fmt.Fprintln(a.Output, pin)
+	}
+
+	return nil
+}
+
+func (a *App) History() error {
+	history, err := a.readHistory()
+	if err != nil {
+		return err
+	}
+
+	for i := len(history) - 1; i >= 0; i-- {
+		entry := history[i]
+		fmt.Fprintf(a.Output, "%s %s\n", entry.time.Format(time.DateTime), entry.version)
+	}
+
+	return nil
+}
+
+// ErrNetworkDisabled is returned by commands that need go.dev if [App.Requester] is nil,
+// e.g. in tests of the offline paths.
+var ErrNetworkDisabled = errors.New("network disabled")
+
+// ErrNetworkDeclined is returned if a network access hasn't been confirmed (see [App.ConfirmNetwork]).
+var ErrNetworkDeclined = errors.New("network access has been declined")
+
+// confirmNetwork asks for confirmation of the network access, if [App.ConfirmNetwork] is set.
+func (a *App) confirmNetwork(description string) error {
+	if a.ConfirmNetwork == nil || a.ConfirmNetwork(description) {
+		return nil
+	}
+	return ErrNetworkDeclined
+}
+
+// ErrNoVersions is returned if go.dev (or a mirror in front of it) responds with an empty list of versions,
+// which usually means a network problem rather than a malformed version.
+var ErrNoVersions = errors.New("the list of versions is empty; check your network or mirror")
+
+// ErrUpdateAvailable is returned by [App.SelfUpdate] in the check mode if a newer release exists,
+// and by [App.List] with [ListOptions.Outdated] if any installed version has a newer patch available.
+var ErrUpdateAvailable = errors.New("update available")
+
+func (a *App) ConfigDir() error {
+	if a.State == nil {
+		return errNoState
+	}
+	fmt.Fprintln(a.Output, a.State.Path("."))
+	return nil
+}
+
+func (a *App) SelfUpdate(ctx context.Context, current string, checkOnly bool) error {
+	if current == "dev" {
+		if checkOnly {
+			fmt.Fprintf(a.Output, "goversion %s is a development build\n", current)
+			return nil
+		}
+		return errors.New("unable to update a development build")
+	}
+
+	latest, err := a.latestRelease(ctx)
+	if err != nil {
+		return err
+	}
+
+	if goversion.Compare("go"+current, "go"+latest) >= 0 {
+		fmt.Fprintf(a.Output, "goversion %s is up to date\n", current)
+		return nil
+	}
+
+	if checkOnly {
+		fmt.Fprintf(a.Output, "goversion %s is available (current: %s)\n", latest, current)
+		return ErrUpdateAvailable
+	}
+
+	if err := a.confirmNetwork("run `go install " + selfModule + "@v" + latest + "` (downloads the module via GOPROXY)"); err != nil {
+		return fmt.Errorf("installing goversion %s: %w", latest, err)
+	}
+	fmt.Fprintf(a.Output, "Updating goversion %s -> %s ...\n", current, latest)
+	if err := a.RunCmd(ctx, "go", "install", selfModule+"@v"+latest); err != nil {
+		return fmt.Errorf("installing goversion %s: %w", latest, err)
+	}
+
+	fmt.Fprintf(a.Output, "Updated goversion to %s\n", latest)
+	return nil
+}
+
+// status returns the porcelain status of the version.
+// The set of statuses is part of the stable output format and must not be changed.
+func (a *App) status(local *local, version string) string {
+	switch {
+	case version == local.current:
+		return "active"
+	case version == local.main:
+		return "main"
+	case !slices.Contains(local.list, version):
+		return "available"
+	case !a.downloaded(version):
+		return "missing-sdk"
+	default:
+		return "installed"
+	}
+}
+
+// resolveSuffix resolves the <major>.<minor>@<suffix> version spec,
+// where suffix is either "latest" (the latest patch) or a patch number.
+// If go.dev is unreachable, "latest" falls back to the latest installed patch.
+func (a *App) resolveSuffix(ctx context.Context, local *local, base, suffix string) (string, error) {
+	if _, _, tail := parseVersion(base); !isValid(base) || strings.Count(base, ".") != 1 || tail != "" {
+		return "", fmt.Errorf("malformed version %q: the @ suffix is only supported for <major>.<minor> versions", base+"@"+suffix)
+	}
+
+	if suffix != "latest" {
+		version := base + "." + suffix
+		if !isValid(version) {
+			return "", fmt.Errorf("malformed version %q", base+"@"+suffix)
+		}
+		return version, nil
+	}
+
+	versions, err := a.remoteVersions(ctx, fileFilter{})
+	if err != nil {
+		if patch := latestPatch(local.list, base); patch != "" && ctx.Err() == nil {
+			a.warnf("unable to get versions from go.dev (%v); using installed %s", err, patch)
+			return patch, nil
+		}
+		return "", err
+	}
+
+	// remote versions are sorted from newest to oldest.
+	for _, version := range versions {
+		if _, _, tail := parseVersion(version); tail == "" && hasVersionPrefix(version, base) {
+			return version, nil
+		}
+	}
+
+	return "", fmt.Errorf("no stable releases of %s have been found", base)
+}
+
+// buildTip builds tip at the ref (a branch or a CL number) using `gotip download <ref>`,
+// unless the current tip build already corresponds to the ref.
+func (a *App) buildTip(ctx context.Context, local *local, ref string, installFlags []string) error {
+	if !slices.Contains(local.list, "tip") {
+		fmt.Fprintf(a.Output, "tip is not installed. Looking for it on go.dev ...\n")
+		if err := a.installBinary(ctx, "tip", installFlags...); err != nil {
+			return err
+		}
+		local.list = append(local.list, "tip")
+	}
+
+	if a.downloaded("tip") && a.tipRef() == ref {
+		fmt.Fprintf(a.Output, "tip is already built at %s\n", ref)
+		return nil
+	}
+
+	if err := a.confirmNetwork("run `gotip download " + ref + "` (clones go.googlesource.com/go)"); err != nil {
+		return fmt.Errorf("building tip at %s: %w", ref, err)
+	}
+	fmt.Fprintf(a.Output, "Building tip at %s ...\n", ref)
+	if err := a.RunCmd(ctx, "gotip", "download", ref); err != nil {
+		return fmt.Errorf("building tip at %s: %w", ref, a.ctxError(ctx, err))
+	}
+	a.recordTipRef(ref)
+	return nil
+}
+
+// installBinary installs the go<version> binary to GOBIN, passing the flags to `go install`.
+func (a *App) installBinary(ctx context.Context, version string, flags ...string) error {
+	url := fmt.Sprintf("golang.org/dl/go%s@latest", version)
+	if err := a.confirmNetwork("run `go install " + url + "` (downloads the module via GOPROXY)"); err != nil {
+		return fmt.Errorf("installing go%s: %w", version, err)
+	}
+	a.emit(EventDownload, version, "installing go"+version+" binary")
+	args := slices.Concat([]string{"install"}, flags, []string{url})
+	if err := a.RunCmd(ctx, "go", args...); err != nil {
+		_ = a.GoBin.Remove("go" + version + exe()) // best effort.
+		return fmt.Errorf("installing go%s: %w", version, a.ctxError(ctx, err))
+	}
+	// an interrupted installation may leave a zero-byte or partial binary behind.
+	if !a.executable("go" + version + exe()) {
+		_ = a.GoBin.Remove("go" + version + exe()) // best effort.
+		return binaryError{fmt.Errorf("go%s binary is missing or broken after installation", version)}
+	}
+	if err := a.verifyBinary(ctx, version); err != nil {
+		_ = a.GoBin.Remove("go" + version + exe()) // best effort.
+		return err
+	}
+	return nil
+}
+
+// verifyBinary checks that the installed go<version> binary is built from the golang.org/dl/go<version> package,
+// e.g. to catch a misbehaving module proxy before downloading the SDK.
+// The wrapper can't run `version` without its SDK, so its build info is read by the main go binary instead.
+func (a *App) verifyBinary(ctx context.Context, version string) error {
+	output, err := a.runMainGo(ctx, "version", "-m", a.GoBin.Path("go"+version+exe()))
+	if err != nil {
+		return fmt.Errorf("verifying go%s: %w", version, a.ctxError(ctx, err))
+	}
+
+	want := "golang.org/dl/go" + version
+	for _, line := range strings.Split(output, "\n") {
+		// e.g. "\tpath\tgolang.org/dl/go1.21.6".
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "path" {
+			if fields[1] != want {
+				return binaryError{fmt.Errorf("go%s binary is built from %s instead of %s; check your GOPROXY", version, fields[1], want)}
+			}
+			return nil
+		}
+	}
+	return nil // no build info to check (e.g. a binary built without module support).
+}
+
+// binaryError is returned if the installed go<version> binary is unusable, which retrying won't fix.
+type binaryError struct{ err error }
+
+func (e binaryError) Error() string { return e.err.Error() }
+func (e binaryError) Unwrap() error { return e.err }
+
+// installBinaryWait calls [App.installBinary] until it succeeds or [UseOptions.Wait] elapses.
+// Declined network access and unusable binaries are not retried.
+// The retries are spaced by a tenth of the wait duration, but at least a second and at most 30 seconds.
+func (a *App) installBinaryWait(ctx context.Context, version string, opts UseOptions) error {
+	wait := opts.Wait
+	deadline := a.now().Add(wait)
+	interval := max(time.Second, min(30*time.Second, wait/10))
+	for {
+		err := a.installBinary(ctx, version, opts.InstallFlags...)
+		if err == nil || ctx.Err() != nil || wait <= 0 {
+			return err
+		}
+		if errors.Is(err, ErrNetworkDeclined) || errors.As(err, new(binaryError)) {
+			return err
+		}
+		if a.now().Add(interval).After(deadline) {
+			return fmt.Errorf("%w (gave up after waiting %s)", err, wait)
+		}
+		a.warnf("go%s is not installable yet (%v); retrying in %s", version, err, interval)
+
+		if err := a.sleep(ctx, interval); err != nil {
+			return a.ctxError(ctx, err)
+		}
+	}
+}
+
+// downloadSDK downloads the SDK using the go<version> binary.
+func (a *App) downloadSDK(ctx context.Context, version string) error {
+	if err := a.confirmNetwork(sdkSource(version)); err != nil {
+		return fmt.Errorf("downloading go%s SDK: %w", version, err)
+	}
+	a.emit(EventDownload, version, "downloading go"+version+" SDK")
+	if err := a.RunCmd(ctx, "go"+version, "download"); err != nil {
+		return fmt.Errorf("downloading go%s SDK: %w", version, a.ctxError(ctx, err))
+	}
+	if version == "tip" {
+		a.recordTipRef("") // gotip download without a ref builds the master branch.
+	}
+	return nil
+}
+
+// sdkSource describes where `go<version> download` gets the SDK from.
+func sdkSource(version string) string {
+	if version == "tip" {
+		return "run `gotip download` (clones go.googlesource.com/go)"
+	}
+	return "run `go" + version + " download` (downloads the SDK from dl.google.com)"
+}
+
+// managed reports whether the go<version> binary exists in $GOBIN.
+func (a *App) managed(version string) bool {
+	_, err := fs.Stat(a.GoBin, "go"+version+exe())
+	return err == nil
+}
+
+func (a *App) executable(name string) bool {
+	info, err := fs.Stat(a.GoBin, name)
+	if err != nil || !info.Mode().IsRegular() || info.Size() == 0 {
+		return false
+	}
+	// Windows has no executable permission bits.
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0o111 != 0
+}
+
+// sdkPath returns the SDK path of the installed version, or an empty string for main and not installed versions.
+func (a *App) sdkPath(local *local, version string) string {
+	if version == local.main || !slices.Contains(local.list, version) {
+		return ""
+	}
+	return a.SDK.Path("go" + version) // gotip for tip.
+}
+
+// sdkSize returns the total size of the SDK files of the version.
+func (a *App) sdkSize(version string) (int64, error) {
+	var size int64
+	err := fs.WalkDir(a.SDK, "go"+version, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// anySDK reports whether the SDK directory contains at least one go* SDK.
+func (a *App) anySDK() bool {
+	entries, err := fs.ReadDir(a.SDK, ".")
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(entries, func(entry fs.DirEntry) bool {
+		return entry.IsDir() && strings.HasPrefix(entry.Name(), "go")
+	})
+}
+
+func (a *App) downloaded(version string) bool {
+	// from https://github.com/golang/dl/blob/master/internal/version/version.go:
+	// .unpacked-success is a sentinel zero-byte file to indicate that the Go version was downloaded and unpacked successfully.
+	name := "go" + version + "/.unpacked-success"
+	if version == "tip" {
+		name = "gotip/bin/go" + exe() // https://github.com/golang/dl/blob/master/internal/version/gotip.go#L45
+	}
+	_, err := fs.Stat(a.SDK, name)
+	return err == nil
+}
+
+type local struct {
+	main    string
+	current string
+	linked  bool     // $GOBIN/go is a symlink or a copy (even if it points to main).
+	list    []string // includes both main and current.
+}
+
+// runMainGo runs the main go binary (i.e. not the $GOBIN/go symlink) and returns its output.
+func (a *App) runMainGo(ctx context.Context, args ...string) (string, error) {
+	// if $GOBIN is in $PATH, the main go binary is looked up in $PATH without $GOBIN.
+	// $PATH itself is not modified, so that concurrent commands (e.g. `go install`) run in an unmodified environment.
+	name := "go"
+	env := a.env()
+	path, _ := env.LookupEnv("PATH")
+	gobin, _ := env.LookupEnv("GOBIN")
+	if cut := cutFromPath(path, gobin); gobin != "" && cut != path {
+		var err error
+		if name, err = a.lookPath("go", cut); err != nil {
+			return "", err
+		}
+	}
+	return a.RunCmdOut(ctx, name, args...)
+}
+
+func (a *App) localVersions(ctx context.Context) (*local, error) {
+	output, err := a.runMainGo(ctx, "version")
+	if err != nil {
+		return nil, err
+	}
+
+	main, ok := parseGoVersion(output)
+	if !ok {
+		return nil, fmt.Errorf("unexpected format %q", output)
+	}
+
+	var current string
+	var linked, copied bool
+	switch info, err := a.GoBin.Lstat("go" + exe()); {
+	case errors.Is(err, fs.ErrNotExist):
+		current = main
+	case err != nil:
+		return nil, err
+	case info.Mode()&fs.ModeSymlink == 0:
+		// the version is determined below, once the list of installed versions is known.
+		linked, copied = true, true
+	default:
+		link, err := a.GoBin.Readlink("go" + exe())
+		if err != nil {
+			return nil, err
+		}
+		if current = binaryVersion(link); current == "" {
+			return nil, fmt.Errorf("$GOBIN/go%s points to %q, which is not a Go binary", exe(), link)
+		}
+		linked = true
+	}
+
+	entries, err := fs.ReadDir(a.GoBin, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	list := []string{main}
+	for _, entry := range entries {
+		// $GOBIN may contain arbitrary tools, so consider only regular files and symlinks;
+		// directories (even those named like go1.X) and special files are skipped.
+		if typ := entry.Type(); !typ.IsRegular() && typ&fs.ModeSymlink == 0 {
+			continue
+		}
+		// the main version may be installed by goversion as well; list it once.
+		if version := binaryVersion(entry.Name()); version != "" && version != main {
+			list = append(list, version)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return versionLess(list[i], list[j])
+	})
+
+	if copied {
+		if current, err = a.copiedVersion(list); err != nil {
+			return nil, err
+		}
+		if current == "" {
+			return nil, fmt.Errorf("$GOBIN/go%s is neither a symlink nor a copy managed by goversion; remove it to continue", exe())
+		}
+	}
+
+	return &local{
+		main:    main,
+		current: current,
+		linked:  linked,
+		list:    list,
+	}, nil
+}
+
+// releasesURL is the go.dev endpoint with all releases, sorted by version, from newest to oldest.
+const releasesURL = "https://go.dev/dl/?mode=json&include=all"
+
+// release is a release in the go.dev JSON format.
+type release struct {
+	Version string        `json:"version"`
+	Stable  bool          `json:"stable"`
+	Files   []releaseFile `json:"files"`
+}
+
+// releaseFile is a downloadable file of a [release].
+type releaseFile struct {
+	Filename string `json:"filename"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Kind     string `json:"kind"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+}
+
+// remoteVersions returns the versions available on go.dev,
+// optionally only those that ship a file matching the filter.
+func (a *App) remoteVersions(ctx context.Context, filter fileFilter) ([]string, error) {
+	var versions []string
+	err := a.fetch(ctx, releasesURL, func(r io.Reader) (err error) {
+		versions, err = a.decodeVersions(r, filter)
+		return err
+	})
+	// the versions always include tip.
+	if !a.HTMLFallback || ctx.Err() != nil || errors.Is(err, ErrNetworkDeclined) || (err == nil && len(versions) > 1) {
+		return versions, err
+	}
+
+	if err != nil {
+		a.warnf("falling back to the go.dev HTML page: %v", err)
+	} else {
+		a.warnf("falling back to the go.dev HTML page: no versions in the JSON")
+	}
+
+	const htmlURL = "https://go.dev/dl/"
+	err = a.fetch(ctx, htmlURL, func(r io.Reader) (err error) {
+		versions, err = scrapeVersions(r, filter)
+		return err
+	})
+	return versions, err
+}
+
+// fetch sends a GET request to the url and decodes the response body,
+// both within [App.Timeout].
+func (a *App) fetch(ctx context.Context, url string, decode func(r io.Reader) error) error {
+	if a.Requester == nil {
+		return fmt.Errorf("fetching %s: %w", url, ErrNetworkDisabled)
+	}
+	if err := a.confirmNetwork("fetch " + url); err != nil {
+		return fmt.Errorf("fetching %s: %w", url, err)
+	}
+
+	if a.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "goversion/"+cmp.Or(a.Version, "dev"))
+
+	resp, err := a.Requester.Do(req)
+	if err != nil {
+		return a.ctxError(ctx, err)
 	}
 	defer resp.Body.Close()
 
-	var list []struct {
-		Version string `json:"version"`
-		Stable  bool   `json:"stable"`
+	if err := decode(resp.Body); err != nil {
+		return a.ctxError(ctx, err)
+	}
+	return nil
+}
+
+// ctxError annotates the error of a timed out or interrupted (e.g. with Ctrl+C) operation,
+// so that it's not reported as a bare context error or a killed subprocess.
+func (a *App) ctxError(ctx context.Context, err error) error {
+	// the context error takes precedence, since e.g. a killed subprocess reports only its exit status.
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		// a custom cause (e.g. the total timeout of the command) describes the error better.
+		if cause := context.Cause(ctx); cause != ctxErr {
+			return cause
+		}
+		err = ctxErr
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) && a.Timeout > 0:
+		return fmt.Errorf("timed out after %s: %w", a.Timeout, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		// the deadline was set by the caller, so its duration is unknown.
+		return fmt.Errorf("timed out: %w", err)
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("interrupted: %w", err)
+	}
+	return err
+}
+
+// decodeVersions decodes the list of versions in the go.dev JSON format.
+// Malformed entries (e.g. from a mirror with a different format) are skipped with a warning.
+// If the filter is not empty, versions that don't ship a matching file are skipped as well.
+func (a *App) decodeVersions(r io.Reader, filter fileFilter) ([]string, error) {
+	var list []release
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
 		return nil, err
 	}
+	if len(list) == 0 {
+		return nil, ErrNoVersions
+	}
 
-	versions := make([]string, len(list)+1)
-	versions[0] = "tip"
-	for i, v := range list {
-		versions[i+1] = strings.TrimPrefix(v.Version, "go")
+	versions := make([]string, 0, len(list)+1)
+	versions = append(versions, "tip")
+	for _, v := range list {
+		version := strings.TrimPrefix(v.Version, "go")
+		if !isValid(version) || version == "tip" {
+			a.warnf("skipping malformed version %q", v.Version)
+			continue
+		}
+		if filter != (fileFilter{}) && !slices.ContainsFunc(v.Files, func(f releaseFile) bool {
+			return filter.match(f.OS, f.Arch, f.Kind)
+		}) {
+			continue
+		}
+		versions = append(versions, version)
 	}
 
+	// go.dev returns the versions sorted, but the input may also come from stdin.
+	sortVersions(versions)
 	return versions, nil
 }
+
+// warnf prints a warning to [App.Errors], if set.
+func (a *App) warnf(format string, args ...any) {
+	if a.Errors != nil {
+		fmt.Fprintf(a.Errors, "Warning: "+format+"\n", args...)
+	}
+}
+
+const selfModule = "go-simpler.org/goversion"
+
+// latestRelease returns the latest released version of goversion itself (without the "v" prefix).
+// It's resolved by the go command, so that GOPROXY, GOPRIVATE, etc. are respected.
+func (a *App) latestRelease(ctx context.Context) (string, error) {
+	if err := a.confirmNetwork("run `go list -m " + selfModule + "@latest` (queries GOPROXY)"); err != nil {
+		return "", fmt.Errorf("resolving the latest release: %w", err)
+	}
+	output, err := a.runMainGo(ctx, "list", "-m", "-json", selfModule+"@latest")
+	if err != nil {
+		return "", fmt.Errorf("resolving the latest release: %w", a.ctxError(ctx, err))
+	}
+
+	var info struct {
+		Version string `json:"Version"`
+	}
+	if err := json.Unmarshal([]byte(output), &info); err != nil {
+		return "", fmt.Errorf("resolving the latest release: %w", err)
+	}
+
+	latest := strings.TrimPrefix(info.Version, "v")
+	if !goversion.IsValid("go" + latest) {
+		return "", fmt.Errorf("unexpected version %q", info.Version)
+	}
+
+	return latest, nil
+}
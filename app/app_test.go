@@ -2,14 +2,22 @@ package app_test
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"go-simpler.org/assert"
 	. "go-simpler.org/assert/EF"
@@ -20,229 +28,2642 @@ func TestApp_Use(t *testing.T) {
 	t.Run("switch to new version", func(t *testing.T) {
 		var steps []string
 
-		app := app.App{
-			GoBin:  spyFS{dir: "bin", calls: &steps},
-			SDK:    spyFS{dir: "sdk", calls: &steps},
+		bin := &spyFS{dir: "bin", calls: &steps}
+		a := app.App{
+			GoBin:  bin,
+			SDK:    &spyFS{dir: "sdk", calls: &steps},
+			State:  &spyFS{dir: "state", calls: &steps},
 			Output: io.Discard,
+			Now:    now,
 		}
-		recordCmds(&app, &steps, "go version go1.20")
+		recordCmds(&a, &steps, "go version go1.20")
+		onInstall(&a, func() { bin.files = append(bin.files, "go1.18") })
 
-		err := app.Use(context.Background(), "1.18")
+		err := a.Use(context.Background(), "1.18", app.UseOptions{})
 		assert.NoErr[F](t, err)
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`,                                                 // 1. read main version
+			`call: bin.Lstat("go")`,                                            // 2. check go symlink
+			`call: bin.ReadDir(".")`,                                           // 3. read installed versions
+			`exec: go install golang.org/dl/go1.18@latest`,                     // 4. install 1.18 binary
+			`call: bin.Stat("go1.18")`,                                         // 5. check 1.18 binary
+			`exec: go version -m /bin/go1.18`,                                  // 6. verify 1.18 binary
+			`call: sdk.Stat("go1.18/.unpacked-success")`,                       // 7. check 1.18 SDK
+			`exec: go1.18 download`,                                            // 8. download 1.18 SDK
+			`call: bin.Remove("go")`,                                           // 9. remove old symlink
+			`call: bin.Symlink("go1.18", "go")`,                                // 10. create new symlink
+			`call: bin.Readlink("go")`,                                         // 11. verify symlink
+			`call: state.WriteFile("previous", "1.20\n")`,                      // 12. save previous version
+			`call: state.ReadFile("history")`,                                  // 13. read history
+			`call: state.WriteFile("history", "2024-01-01T00:00:00Z\t1.18\n")`, // 14. append to history
+			`call: state.ReadFile("config")`,                                   // 15. read post-use hook
+		})
+	})
+
+	t.Run("switch to new version (events)", func(t *testing.T) {
+		var events []app.Event
+
+		bin := &spyFS{dir: "bin", calls: new([]string)}
+		a := app.App{
+			GoBin:   bin,
+			SDK:     &spyFS{dir: "sdk", calls: new([]string)},
+			State:   &spyFS{dir: "state", calls: new([]string)},
+			Output:  io.Discard,
+			Now:     now,
+			OnEvent: func(e app.Event) { events = append(events, e) },
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+		onInstall(&a, func() { bin.files = append(bin.files, "go1.18") })
+
+		err := a.Use(context.Background(), "1.18", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, events, []app.Event{
+			{Type: app.EventStart, Version: "1.18", Message: "switching"},
+			{Type: app.EventDownload, Version: "1.18", Message: "installing go1.18 binary"},
+			{Type: app.EventDownload, Version: "1.18", Message: "downloading go1.18 SDK"},
+			{Type: app.EventSwitch, Version: "1.18", Message: "switched from 1.20"},
+			{Type: app.EventDone, Version: "1.18", Message: "switched"},
+		})
+	})
+
+	t.Run("switch to latest patch", func(t *testing.T) {
+		var steps []string
+
+		bin := &spyFS{dir: "bin", calls: &steps}
+		a := app.App{
+			GoBin:  bin,
+			SDK:    &spyFS{dir: "sdk", files: []string{"go1.21.6/.unpacked-success"}, calls: &steps},
+			State:  &spyFS{dir: "state", calls: new([]string)},
+			Output: io.Discard,
+			Now:    now,
+			Requester: httpSpy{
+				requests: &steps,
+				response: `[{"version":"go1.22rc1"},{"version":"go1.21.6"},{"version":"go1.21.5"},{"version":"go1.21rc2"}]`,
+			},
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+		onInstall(&a, func() { bin.files = append(bin.files, "go1.21.6") })
+
+		err := a.Use(context.Background(), "1.21@latest", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`,                               // 1. read main version
+			`call: bin.Lstat("go")`,                          // 2. check go symlink
+			`call: bin.ReadDir(".")`,                         // 3. read installed versions
+			`http: https://go.dev/dl/?mode=json&include=all`, // 4. get remote versions
+			`exec: go install golang.org/dl/go1.21.6@latest`, // 5. install 1.21.6 binary
+			`call: bin.Stat("go1.21.6")`,                     // 6. check 1.21.6 binary
+			`exec: go version -m /bin/go1.21.6`,              // 7. verify 1.21.6 binary
+			`call: sdk.Stat("go1.21.6/.unpacked-success")`,   // 8. check 1.21.6 SDK
+			`call: bin.Remove("go")`,                         // 9. remove old symlink
+			`call: bin.Symlink("go1.21.6", "go")`,            // 10. create new symlink
+			`call: bin.Readlink("go")`,                       // 11. verify symlink
+		})
+	})
+
+	t.Run("switch to latest patch (go.dev unreachable)", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				files: []string{"go1.21.1", "go1.21.3", "go1.22rc1"},
+				calls: &steps,
+			},
+			SDK:       &spyFS{dir: "sdk", files: []string{"go1.21.3/.unpacked-success"}, calls: &steps},
+			State:     &spyFS{dir: "state", calls: new([]string)},
+			Output:    io.Discard,
+			Errors:    &buf,
+			Now:       now,
+			Requester: httpSpy{requests: &steps, err: errors.New("no such host")},
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Use(context.Background(), "1.21@latest", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "Warning: unable to get versions from go.dev (no such host); using installed 1.21.3\n")
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`,                               // 1. read main version
+			`call: bin.Lstat("go")`,                          // 2. check go symlink
+			`call: bin.ReadDir(".")`,                         // 3. read installed versions
+			`http: https://go.dev/dl/?mode=json&include=all`, // 4. get remote versions (fails)
+			`call: sdk.Stat("go1.21.3/.unpacked-success")`,   // 5. check 1.21.3 SDK
+			`call: bin.Remove("go")`,                         // 6. remove old symlink
+			`call: bin.Symlink("go1.21.3", "go")`,            // 7. create new symlink
+			`call: bin.Readlink("go")`,                       // 8. verify symlink
+		})
+
+		// no installed patch to fall back to.
+		err = a.Use(context.Background(), "1.19@latest", app.UseOptions{})
+		assert.Equal[F](t, err.Error(), "no such host")
+	})
+
+	t.Run("switch to explicit patch", func(t *testing.T) {
+		var steps []string
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", files: []string{"go1.21.3"}, calls: &steps},
+			SDK:    &spyFS{dir: "sdk", files: []string{"go1.21.3/.unpacked-success"}, calls: &steps},
+			State:  &spyFS{dir: "state", calls: new([]string)},
+			Output: io.Discard,
+			Now:    now,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Use(context.Background(), "1.21@3", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, steps[len(steps)-2], `call: bin.Symlink("go1.21.3", "go")`)
+
+		for _, version := range []string{"tip@", "main@latest", "1.21.3@latest", "1.21rc1@latest", "1.21@x"} {
+			err := a.Use(context.Background(), version, app.UseOptions{})
+			assert.Equal[E](t, err != nil, true, version)
+		}
+	})
+
+	t.Run("switch to tip at ref", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		state := &spyFS{dir: "state", contents: map[string]string{"tip-ref": "11111\n"}, calls: new([]string)}
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", files: []string{"go1.18", "gotip"}, calls: new([]string)},
+			SDK:    &spyFS{dir: "sdk", files: []string{"gotip/bin/go"}, calls: new([]string)},
+			State:  state,
+			Output: &buf,
+			Now:    now,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		// 1. build tip at the new ref
+		err := a.Use(context.Background(), "tip@12345", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, state.contents["tip-ref"], "12345\n")
+		assert.Equal[E](t, buf.String(), "Building tip at 12345 ...\nSwitched to tip (was 1.20, main)\n")
+
+		// 2. skip rebuilding tip at the same ref
+		buf.Reset()
+		err = a.Use(context.Background(), "tip@12345", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "tip is already built at 12345\ntip is already in use\n")
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`,           // 1. read main version
+			`exec: gotip download 12345`, // 2. build tip at the ref
+			`exec: go version`,           // 3. read main version
+		})
+	})
+
+	t.Run("download only", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		bin := &spyFS{dir: "bin", calls: &steps}
+		a := app.App{
+			GoBin:  bin,
+			SDK:    &spyFS{dir: "sdk", calls: &steps},
+			State:  &spyFS{dir: "state", calls: &steps},
+			Output: &buf,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+		onInstall(&a, func() { bin.files = append(bin.files, "go1.18") })
+
+		err := a.Use(context.Background(), "1.18", app.UseOptions{DownloadOnly: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), ""+
+			"1.18 is not installed. Looking for it on go.dev ...\n"+
+			"1.18 is ready\n")
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`,                             // 1. read main version
+			`call: bin.Lstat("go")`,                        // 2. check go symlink
+			`call: bin.ReadDir(".")`,                       // 3. read installed versions
+			`exec: go install golang.org/dl/go1.18@latest`, // 4. install 1.18 binary
+			`call: bin.Stat("go1.18")`,                     // 5. check 1.18 binary
+			`exec: go version -m /bin/go1.18`,              // 6. verify 1.18 binary
+			`call: sdk.Stat("go1.18/.unpacked-success")`,   // 7. check 1.18 SDK
+			`exec: go1.18 download`,                        // 8. download 1.18 SDK
+		})
+	})
+
+	t.Run("switch to missing version (fail if missing)", func(t *testing.T) {
+		var steps []string
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", files: []string{"go1.19"}, calls: &steps},
+			SDK:    &spyFS{dir: "sdk", calls: &steps},
+			Output: io.Discard,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Use(context.Background(), "1.18", app.UseOptions{FailIfMissing: true})
+		assert.IsErr[F](t, err, app.ErrNotInstalled)
+		assert.Equal[E](t, err.Error(), "1.18: version is not installed")
+
+		// 1.19 is installed, but its SDK is missing.
+		err = a.Use(context.Background(), "1.19", app.UseOptions{FailIfMissing: true})
+		assert.IsErr[F](t, err, app.ErrNotInstalled)
+		assert.Equal[E](t, slices.ContainsFunc(steps, func(s string) bool { return strings.HasPrefix(s, "exec: go install") }), false)
+	})
+
+	t.Run("switch to new version (environment)", func(t *testing.T) {
+		path := strings.Join([]string{"/path/to/gobin", "/usr/local/go/bin"}, string(os.PathListSeparator))
+		env := mapEnv{"GOBIN": "/path/to/gobin", "PATH": path}
+		realPath := os.Getenv("PATH")
+
+		var cmds []string
+		bin := &spyFS{dir: "bin", calls: new([]string)}
+		a := app.App{
+			GoBin:  bin,
+			SDK:    &spyFS{dir: "sdk", calls: new([]string)},
+			State:  &spyFS{dir: "state", calls: new([]string)},
+			Output: io.Discard,
+			Env:    env,
+			Now:    now,
+			RunCmd: func(ctx context.Context, name string, args ...string) error {
+				cmds = append(cmds, name+" with PATH="+env["PATH"])
+				return nil
+			},
+			RunCmdOut: func(ctx context.Context, name string, args ...string) (string, error) {
+				cmds = append(cmds, name+" with PATH="+env["PATH"])
+				return "go version go1.20", nil
+			},
+			LookPath: func(file, path string) (string, error) {
+				return path + "/" + file, nil
+			},
+		}
+		onInstall(&a, func() { bin.files = append(bin.files, "go1.18") })
+
+		err := a.Use(context.Background(), "1.18", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, cmds, []string{
+			"/usr/local/go/bin/go with PATH=" + path, // 1. go version (looked up without $GOBIN)
+			"go with PATH=" + path,                   // 2. go install
+			"/usr/local/go/bin/go with PATH=" + path, // 3. go version -m (looked up without $GOBIN)
+			"go1.18 with PATH=" + path,               // 4. go1.18 download
+		})
+		assert.Equal[E](t, env["PATH"], path)
+		assert.Equal[E](t, os.Getenv("PATH"), realPath) // the real environment is untouched.
+	})
+
+	t.Run("switch to new version (install failed)", func(t *testing.T) {
+		var steps []string
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", calls: &steps},
+			SDK:    &spyFS{dir: "sdk", calls: &steps},
+			Output: io.Discard,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		errInstall := errors.New("exit status 1")
+		a.RunCmd = func(context.Context, string, ...string) error { return errInstall }
+
+		err := a.Use(context.Background(), "1.18", app.UseOptions{})
+		assert.IsErr[F](t, err, errInstall)
+		assert.Equal[E](t, err.Error(), "installing go1.18: exit status 1")
+	})
+
+	t.Run("switch to new version (broken binary)", func(t *testing.T) {
+		var steps []string
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", calls: &steps},
+			SDK:    &spyFS{dir: "sdk", calls: &steps},
+			Output: io.Discard,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Use(context.Background(), "1.18", app.UseOptions{})
+		assert.Equal[F](t, err.Error(), "go1.18 binary is missing or broken after installation")
 		assert.Equal[E](t, steps, []string{
 			`exec: go version`,                             // 1. read main version
-			`call: bin.Readlink("go")`,                     // 2. read current version
+			`call: bin.Lstat("go")`,                        // 2. check go symlink
 			`call: bin.ReadDir(".")`,                       // 3. read installed versions
 			`exec: go install golang.org/dl/go1.18@latest`, // 4. install 1.18 binary
-			`call: sdk.Stat("go1.18/.unpacked-success")`,   // 5. check 1.18 SDK
-			`exec: go1.18 download`,                        // 6. download 1.18 SDK
-			`call: bin.Remove("go")`,                       // 7. remove old symlink
-			`call: bin.Symlink("go1.18", "go")`,            // 8. create new symlink
+			`call: bin.Stat("go1.18")`,                     // 5. check 1.18 binary
+			`call: bin.Remove("go1.18")`,                   // 6. remove broken 1.18 binary
+		})
+	})
+
+	t.Run("switch to new version (unexpected module)", func(t *testing.T) {
+		var steps []string
+
+		bin := &spyFS{dir: "bin", calls: &steps}
+		a := app.App{
+			GoBin:  bin,
+			SDK:    &spyFS{dir: "sdk", calls: &steps},
+			Output: io.Discard,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+		onInstall(&a, func() { bin.files = append(bin.files, "go1.18") })
+		runCmdOut := a.RunCmdOut
+		a.RunCmdOut = func(ctx context.Context, name string, args ...string) (string, error) {
+			if len(args) > 1 && args[1] == "-m" {
+				_, _ = runCmdOut(ctx, name, args...)
+				return "/bin/go1.18: go1.21.6\n\tpath\tgolang.org/dl/go1.17\n\tmod\tgolang.org/dl\tv0.0.0\n", nil
+			}
+			return runCmdOut(ctx, name, args...)
+		}
+
+		err := a.Use(context.Background(), "1.18", app.UseOptions{})
+		assert.Equal[F](t, err.Error(), "go1.18 binary is built from golang.org/dl/go1.17 instead of golang.org/dl/go1.18; check your GOPROXY")
+		assert.Equal[E](t, steps[len(steps)-2:], []string{
+			`exec: go version -m /bin/go1.18`, // 1. verify 1.18 binary
+			`call: bin.Remove("go1.18")`,      // 2. remove unexpected 1.18 binary
+		})
+	})
+
+	t.Run("switch with unmanaged go binary", func(t *testing.T) {
+		var steps []string
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:      "bin",
+				files:    []string{"go", "go1.19"},
+				contents: map[string]string{"go": "unknown binary", "go1.19": "go1.19 wrapper"},
+				calls:    &steps,
+			},
+			SDK:    &spyFS{dir: "sdk", calls: &steps},
+			Output: io.Discard,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Use(context.Background(), "1.18", app.UseOptions{})
+		assert.Equal[F](t, err.Error(), "$GOBIN/go is neither a symlink nor a copy managed by goversion; remove it to continue")
+	})
+
+	t.Run("switch with copied go binary", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		bin := &spyFS{
+			dir:      "bin",
+			files:    []string{"go1.18", "go1.19"},
+			contents: map[string]string{"go": "go1.19 wrapper", "go1.18": "go1.18 wrapper", "go1.19": "go1.19 wrapper"},
+			calls:    &steps,
+		}
+		a := app.App{
+			GoBin:      bin,
+			SDK:        &spyFS{dir: "sdk", files: []string{"go1.18/.unpacked-success"}, calls: &steps},
+			State:      &spyFS{dir: "state", calls: new([]string)},
+			Output:     &buf,
+			Now:        now,
+			CopyBinary: true,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Use(context.Background(), "1.18", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, bin.contents["go"], "go1.18 wrapper")
+		assert.Equal[E](t, buf.String(), "Switched to 1.18 (was 1.19)\n")
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`,                            // 1. read main version
+			`call: bin.Lstat("go")`,                       // 2. check go binary
+			`call: bin.ReadDir(".")`,                      // 3. read installed versions
+			`call: bin.ReadFile("go")`,                    // 4. read current version
+			`call: bin.ReadFile("go1.20")`,                // 5. compare with 1.20 (main, not in $GOBIN)
+			`call: bin.ReadFile("go1.19")`,                // 6. compare with 1.19
+			`call: sdk.Stat("go1.18/.unpacked-success")`,  // 7. check 1.18 SDK
+			`call: bin.Remove("go")`,                      // 8. remove old copy
+			`call: bin.ReadFile("go1.18")`,                // 9. read 1.18 binary
+			`call: bin.WriteFile("go", "go1.18 wrapper")`, // 10. copy 1.18 binary
+		})
+	})
+
+	t.Run("switch with read-only state", func(t *testing.T) {
+		var buf, errs bytes.Buffer
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", files: []string{"go1.18", "go1.19"}, calls: new([]string)},
+			SDK:    &spyFS{dir: "sdk", files: []string{"go1.18/.unpacked-success", "go1.19/.unpacked-success"}, calls: new([]string)},
+			State:  &spyFS{dir: "state", readOnly: true, calls: new([]string)},
+			Output: &buf,
+			Errors: &errs,
+			Now:    now,
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.Use(context.Background(), "1.18", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		err = a.Use(context.Background(), "1.19", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "Switched to 1.18 (was 1.20, main)\nSwitched to 1.19 (was 1.18)\n")
+		assert.Equal[E](t, errs.String(), "Warning: unable to save the state, so the history and the previous version won't be updated: open state/previous: permission denied\n")
+	})
+
+	t.Run("switch with post-use hook", func(t *testing.T) {
+		var steps []string
+		var errs bytes.Buffer
+
+		env := mapEnv{}
+		a := app.App{
+			GoBin: &spyFS{dir: "bin", files: []string{"go1.18"}, calls: new([]string)},
+			SDK:   &spyFS{dir: "sdk", files: []string{"go1.18/.unpacked-success"}, calls: new([]string)},
+			State: &spyFS{
+				dir:      "state",
+				contents: map[string]string{"config": "# per-version setup\nhook.post_use = go env -w 'GOFLAGS=-mod=mod -trimpath'\n"},
+				calls:    new([]string),
+			},
+			Output: io.Discard,
+			Errors: &errs,
+			Env:    env,
+			Now:    now,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+		var hookEnv, hookArgs []string
+		a.RunCmdEnv = func(_ context.Context, env []string, name string, args ...string) error {
+			hookEnv, hookArgs = env, append([]string{name}, args...)
+			return errors.New("exit status 1")
+		}
+
+		err := a.Use(context.Background(), "1.18", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, hookEnv, []string{"GOVERSION_NEW=1.18"})
+		assert.Equal[E](t, hookArgs, []string{"go", "env", "-w", "GOFLAGS=-mod=mod -trimpath"})
+		assert.Equal[E](t, len(env), 0) // the environment of the process is not modified.
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`, // 1. read main version
+		})
+		assert.Equal[E](t, errs.String(), "Warning: post-use hook failed: exit status 1\n")
+	})
+
+	t.Run("switch with misbehaving symlink", func(t *testing.T) {
+		a := app.App{
+			GoBin: &spyFS{
+				dir:     "bin",
+				files:   []string{"go1.18"},
+				badLink: "/mnt/nfs/go1.17",
+				calls:   new([]string),
+			},
+			SDK:    &spyFS{dir: "sdk", files: []string{"go1.18/.unpacked-success"}, calls: new([]string)},
+			Output: io.Discard,
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.Use(context.Background(), "1.18", app.UseOptions{})
+		assert.Equal[F](t, err.Error(), `$GOBIN/go points to "/mnt/nfs/go1.17" instead of go1.18 after switching`)
+	})
+
+	t.Run("switch to version with trailing newline", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", files: []string{"go1.21.6"}, calls: new([]string)},
+			SDK:    &spyFS{dir: "sdk", files: []string{"go1.21.6/.unpacked-success"}, calls: new([]string)},
+			State:  &spyFS{dir: "state", calls: new([]string)},
+			Output: &buf,
+			Now:    now,
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.Use(context.Background(), "1.21.6\n", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "Switched to 1.21.6 (was 1.20, main)\n")
+
+		buf.Reset()
+		err = a.Remove(context.Background(), " 1.21.6\n", app.RemoveOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "Switched to 1.20 (main)\nRemoved 1.21.6\n")
+	})
+
+	t.Run("switch to new version (install flags)", func(t *testing.T) {
+		var steps []string
+
+		bin := &spyFS{dir: "bin", calls: new([]string)}
+		a := app.App{
+			GoBin:  bin,
+			SDK:    &spyFS{dir: "sdk", calls: new([]string)},
+			Output: io.Discard,
+			Now:    now,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+		onInstall(&a, func() { bin.files = append(bin.files, "go1.22.1") })
+
+		err := a.Use(context.Background(), "1.22.1", app.UseOptions{DownloadOnly: true, InstallFlags: []string{"-x", "-ldflags", "-s -w"}})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`, // 1. read main version
+			`exec: go install -x -ldflags -s -w golang.org/dl/go1.22.1@latest`, // 2. install 1.22.1 binary with the flags
+			`exec: go version -m /bin/go1.22.1`,                                // 3. verify 1.22.1 binary
+			`exec: go1.22.1 download`,                                          // 4. download 1.22.1 SDK without the flags
+		})
+	})
+
+	t.Run("switch with network confirmation", func(t *testing.T) {
+		var steps, asked []string
+
+		a := app.App{
+			GoBin:     &spyFS{dir: "bin", files: []string{"go1.21.3"}, calls: new([]string)},
+			SDK:       &spyFS{dir: "sdk", files: []string{"go1.21.3/.unpacked-success"}, calls: new([]string)},
+			Output:    io.Discard,
+			Errors:    io.Discard,
+			Now:       now,
+			Requester: httpSpy{requests: &steps, response: `[{"version":"go1.21.6"}]`},
+			ConfirmNetwork: func(description string) bool {
+				asked = append(asked, description)
+				return false
+			},
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		// 1. the declined lookup falls back to the installed patch.
+		err := a.Use(context.Background(), "1.21@latest", app.UseOptions{PrintOnly: true})
+		assert.NoErr[F](t, err)
+
+		// 2. the declined installation fails.
+		err = a.Use(context.Background(), "1.22.1", app.UseOptions{})
+		assert.IsErr[F](t, err, app.ErrNetworkDeclined)
+		assert.Equal[E](t, app.ErrorCode(err), "network_declined")
+
+		assert.Equal[E](t, asked, []string{
+			"fetch https://go.dev/dl/?mode=json&include=all",
+			"run `go install golang.org/dl/go1.22.1@latest` (downloads the module via GOPROXY)",
+		})
+		assert.Equal[E](t, slices.ContainsFunc(steps, func(s string) bool {
+			return strings.HasPrefix(s, "http:") || strings.HasPrefix(s, "exec: go install")
+		}), false)
+	})
+
+	t.Run("switch with waiting for installation", func(t *testing.T) {
+		var errs bytes.Buffer
+		clock := now()
+
+		bin := &spyFS{dir: "bin", calls: new([]string)}
+		a := app.App{
+			GoBin:  bin,
+			SDK:    &spyFS{dir: "sdk", calls: new([]string)},
+			Output: io.Discard,
+			Errors: &errs,
+			Now:    func() time.Time { return clock },
+			Sleep:  func(_ context.Context, d time.Duration) error { clock = clock.Add(d); return nil },
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+		runCmd := a.RunCmd
+		var attempts int
+		a.RunCmd = func(ctx context.Context, name string, args ...string) error {
+			if name == "go" && args[0] == "install" {
+				if attempts++; attempts < 3 {
+					return errors.New("exit status 1")
+				}
+				bin.files = append(bin.files, "go1.23.0")
+			}
+			return runCmd(ctx, name, args...)
+		}
+
+		err := a.Use(context.Background(), "1.23.0", app.UseOptions{DownloadOnly: true, Wait: time.Minute})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, attempts, 3)
+		assert.Equal[E](t, clock.Sub(now()), 12*time.Second)
+		assert.Equal[E](t, errs.String(), strings.Repeat("Warning: go1.23.0 is not installable yet (installing go1.23.0: exit status 1); retrying in 6s\n", 2))
+	})
+
+	t.Run("switch with waiting for installation (gave up)", func(t *testing.T) {
+		clock := now()
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", calls: new([]string)},
+			SDK:    &spyFS{dir: "sdk", calls: new([]string)},
+			Output: io.Discard,
+			Errors: io.Discard,
+			Now:    func() time.Time { return clock },
+			Sleep:  func(_ context.Context, d time.Duration) error { clock = clock.Add(d); return nil },
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+		var attempts int
+		a.RunCmd = func(context.Context, string, ...string) error { attempts++; return errors.New("exit status 1") }
+
+		err := a.Use(context.Background(), "1.23.0", app.UseOptions{Wait: 5 * time.Second})
+		assert.Equal[E](t, err.Error(), "installing go1.23.0: exit status 1 (gave up after waiting 5s)")
+		assert.Equal[E](t, attempts, 6) // the minimum interval is a second.
+	})
+
+	t.Run("switch with waiting for installation (declined)", func(t *testing.T) {
+		var errs bytes.Buffer
+		var prompts int
+
+		a := app.App{
+			GoBin:          &spyFS{dir: "bin", calls: new([]string)},
+			SDK:            &spyFS{dir: "sdk", calls: new([]string)},
+			Output:         io.Discard,
+			Errors:         &errs,
+			ConfirmNetwork: func(string) bool { prompts++; return false },
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.Use(context.Background(), "1.23.0", app.UseOptions{Wait: time.Hour})
+		assert.IsErr[F](t, err, app.ErrNetworkDeclined)
+		assert.Equal[E](t, prompts, 1)
+		assert.Equal[E](t, errs.String(), "")
+	})
+
+	t.Run("print only", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", calls: &steps},
+			SDK:    &spyFS{dir: "sdk", calls: &steps},
+			Output: &buf,
+			Requester: httpSpy{
+				requests: &steps,
+				response: `[{"version":"go1.22rc1"},{"version":"go1.21.6"},{"version":"go1.21.5"}]`,
+			},
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Use(context.Background(), "1.21@latest", app.UseOptions{PrintOnly: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "1.21.6\n")
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`,                               // 1. read main version
+			`call: bin.Lstat("go")`,                          // 2. check go symlink
+			`call: bin.ReadDir(".")`,                         // 3. read installed versions
+			`http: https://go.dev/dl/?mode=json&include=all`, // 4. get remote versions
+		})
+
+		buf.Reset()
+		err = a.Use(context.Background(), "main", app.UseOptions{PrintOnly: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "1.20\n")
+	})
+
+	t.Run("switch and replace minor", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		bin := &spyFS{dir: "bin", files: []string{"go1.21.4", "go1.21.5", "go1.21.6", "go1.20.1"}, calls: new([]string)}
+		a := app.App{
+			GoBin: bin,
+			SDK: &spyFS{dir: "sdk", files: []string{
+				"go1.21.4/.unpacked-success",
+				"go1.21.5/.unpacked-success",
+				"go1.21.6/.unpacked-success",
+				"go1.20.1/.unpacked-success",
+			}, calls: new([]string)},
+			State:  &spyFS{dir: "state", contents: map[string]string{"pins": "1.21.4\n"}, calls: new([]string)},
+			Output: &buf,
+			Now:    now,
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.Use(context.Background(), "1.21.6", app.UseOptions{ReplaceMinor: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "Switched to 1.21.6 (was 1.20, main)\nRemoved 1.21.5\n")
+	})
+
+	t.Run("switch to current version", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				link:  "/path/to/go1.18",
+				files: []string{"go1.18"},
+				calls: &steps,
+			},
+			SDK: &spyFS{
+				dir:   "sdk",
+				files: []string{"go1.18/.unpacked-success"},
+				calls: &steps,
+			},
+			Output: &buf,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Use(context.Background(), "1.18", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "1.18 is already in use\n")
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`,         // 1. read main version
+			`call: bin.Lstat("go")`,    // 2. check go symlink
+			`call: bin.Readlink("go")`, // 3. read current version
+			`call: bin.ReadDir(".")`,   // 4. read installed versions
+		})
+	})
+
+	t.Run("switch to main version (mislinked)", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				link:  "/path/to/go1.20",
+				files: []string{"go1.20"},
+				calls: &steps,
+			},
+			SDK:    &spyFS{dir: "sdk", calls: &steps},
+			Output: &buf,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Use(context.Background(), "1.20", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "1.20 is already in use\n")
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`,         // 1. read main version
+			`call: bin.Lstat("go")`,    // 2. check go symlink
+			`call: bin.Readlink("go")`, // 3. read current version
+			`call: bin.ReadDir(".")`,   // 4. read installed versions
+			`call: bin.Remove("go")`,   // 5. remove redundant symlink
+		})
+	})
+
+	t.Run("switch to main version", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				link:  "/path/to/go1.18",
+				files: []string{"go1.18"},
+				calls: &steps,
+			},
+			SDK: &spyFS{
+				dir:   "sdk",
+				files: []string{"go1.18/.unpacked-success"},
+				calls: &steps,
+			},
+			State:  &spyFS{dir: "state", calls: &steps},
+			Output: &buf,
+			Now:    now,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Use(context.Background(), "main", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "Switched to 1.20 (main, was 1.18)\n")
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`,                                                 // 1. read main version
+			`call: bin.Lstat("go")`,                                            // 2. check go symlink
+			`call: bin.Readlink("go")`,                                         // 3. read current version
+			`call: bin.ReadDir(".")`,                                           // 4. read installed versions
+			`call: bin.Remove("go")`,                                           // 5. remove symlink (switch to main)
+			`call: state.WriteFile("previous", "1.18\n")`,                      // 6. save previous version
+			`call: state.ReadFile("history")`,                                  // 7. read history
+			`call: state.WriteFile("history", "2024-01-01T00:00:00Z\t1.20\n")`, // 8. append to history
+			`call: state.ReadFile("config")`,                                   // 9. read post-use hook
+		})
+	})
+
+	t.Run("switch to previous version", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				files: []string{"go1.18"},
+				calls: &steps,
+			},
+			SDK: &spyFS{
+				dir:   "sdk",
+				files: []string{"go1.18/.unpacked-success"},
+				calls: &steps,
+			},
+			State: &spyFS{
+				dir:      "state",
+				contents: map[string]string{"previous": "1.18\n"},
+				calls:    &steps,
+			},
+			Output: &buf,
+			Now:    now,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Use(context.Background(), "-", app.UseOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "Switched to 1.18 (was 1.20, main)\n")
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`,                                                 // 1. read main version
+			`call: bin.Lstat("go")`,                                            // 2. check go symlink
+			`call: bin.ReadDir(".")`,                                           // 3. read installed versions
+			`call: state.ReadFile("previous")`,                                 // 4. read previous version
+			`call: sdk.Stat("go1.18/.unpacked-success")`,                       // 5. check 1.18 SDK
+			`call: bin.Remove("go")`,                                           // 6. remove old symlink
+			`call: bin.Symlink("go1.18", "go")`,                                // 7. create new symlink
+			`call: bin.Readlink("go")`,                                         // 8. verify symlink
+			`call: state.WriteFile("previous", "1.20\n")`,                      // 9. save previous version
+			`call: state.ReadFile("history")`,                                  // 10. read history
+			`call: state.WriteFile("history", "2024-01-01T00:00:00Z\t1.18\n")`, // 11. append to history
+			`call: state.ReadFile("config")`,                                   // 12. read post-use hook
+		})
+	})
+
+	t.Run("switch to previous version (not recorded)", func(t *testing.T) {
+		var steps []string
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", calls: &steps},
+			SDK:    &spyFS{dir: "sdk", calls: &steps},
+			State:  &spyFS{dir: "state", calls: &steps},
+			Output: io.Discard,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Use(context.Background(), "-", app.UseOptions{})
+		assert.Equal[F](t, err.Error(), "no previous version has been recorded")
+	})
+}
+
+func TestParseVersionFile(t *testing.T) {
+	version, err := app.ParseVersionFile([]byte("# the version used in CI\n\n  1.21.6  \r\n1.20\n"))
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, version, "1.21.6")
+
+	_, err = app.ParseVersionFile([]byte("# nothing here\n\n"))
+	assert.Equal[E](t, err.Error(), "no version has been found")
+
+	_, err = app.ParseVersionFile([]byte("go1.21.6\n"))
+	assert.Equal[E](t, err.Error(), `malformed version "go1.21.6"`)
+}
+
+func TestParseGoMod(t *testing.T) {
+	tests := map[string]struct {
+		data string
+		want string
+		err  string
+	}{
+		"go and toolchain": {"module example.com/hello\n\ngo 1.21.0\n\ntoolchain go1.21.6\n", "1.21.6", ""},
+		"go only":          {"module example.com/hello\n\ngo 1.21.0 // the minimum\n\nrequire example.com/dep v1.0.0\n", "1.21.0", ""},
+		"custom toolchain": {"go 1.21.0\ntoolchain go1.21.6-custom\n", "1.21.6", ""},
+		"language version": {"go 1.22\n", "1.22.0", ""},
+		"old language":     {"go 1.20\n", "1.20", ""},
+		"default":          {"go 1.22rc1\ntoolchain default\n", "1.22rc1", ""},
+		"no directives":    {"module example.com/hello\n", "", "neither toolchain nor go directive has been found"},
+		"malformed":        {"go 1.x\n", "", `malformed version "1.x"`},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := app.ParseGoMod([]byte(tt.data))
+			if tt.err != "" {
+				assert.Equal[E](t, err.Error(), tt.err)
+				return
+			}
+			assert.NoErr[F](t, err)
+			assert.Equal[E](t, got, tt.want)
+		})
+	}
+}
+
+func TestModVersion(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "cmd", "hello")
+	err := os.MkdirAll(dir, 0o755)
+	assert.NoErr[F](t, err)
+	err = os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/hello\n\ngo 1.21.0\n"), 0o644)
+	assert.NoErr[F](t, err)
+
+	version, err := app.ModVersion(dir)
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, version, "1.21.0")
+}
+
+func TestSplitArgs(t *testing.T) {
+	args, err := app.SplitArgs(` -x  -ldflags "-s -w" -tags='a b'` + "\t-v\n")
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, args, []string{"-x", "-ldflags", "-s -w", "-tags=a b", "-v"})
+
+	args, err = app.SplitArgs(`-gcflags ""`)
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, args, []string{"-gcflags", ""})
+
+	_, err = app.SplitArgs(`-ldflags "-s -w`)
+	assert.Equal[E](t, err.Error(), `unterminated " quote in "-ldflags \"-s -w"`)
+}
+
+func TestApp_List(t *testing.T) {
+	t.Run("list local versions", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				link:  "/path/to/go1.18",
+				files: []string{"go1.18", "go1.19"},
+				calls: &steps,
+			},
+			SDK: &spyFS{
+				dir:   "sdk",
+				files: []string{"go1.18/.unpacked-success"}, // 1.19 SDK is missing.
+				calls: &steps,
+			},
+			Output: &buf,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, "\n"+buf.String(), `
+  1.20 (main)
+  1.19 (missing SDK)
+* 1.18
+`)
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`,                           // 1. read main version
+			`call: bin.Lstat("go")`,                      // 2. check go symlink
+			`call: bin.Readlink("go")`,                   // 3. read current version
+			`call: bin.ReadDir(".")`,                     // 4. read installed versions
+			`call: sdk.Stat("go1.19/.unpacked-success")`, // 5. check 1.19 SDK
+			`call: sdk.Stat("go1.18/.unpacked-success")`, // 6. check 1.18 SDK
+			`call: sdk.ReadDir(".")`,                     // 7. check SDK directory
+		})
+	})
+
+	t.Run("list local versions (no SDKs)", func(t *testing.T) {
+		var steps []string
+		var buf, errs bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				files: []string{"go1.18"},
+				calls: &steps,
+			},
+			SDK:    &spyFS{dir: "sdk", calls: &steps},
+			Output: &buf,
+			Errors: &errs,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, "\n"+buf.String(), `
+* 1.20 (main)
+  1.18 (missing SDK)
+`)
+		assert.Equal[E](t, errs.String(), "Warning: no SDKs were found; goversion expects them in $HOME/sdk\n")
+	})
+
+	t.Run("list active version only", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				link:  "/path/to/go1.19",
+				files: []string{"go1.18", "go1.19"},
+				calls: new([]string),
+			},
+			SDK: &spyFS{
+				dir:   "sdk",
+				files: []string{"go1.18/.unpacked-success"}, // 1.19 SDK is missing.
+				calls: new([]string),
+			},
+			Output: &buf,
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{ActiveOnly: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "* 1.19 (missing SDK)\n")
+
+		buf.Reset()
+		err = a.List(context.Background(), app.ListOptions{ActiveOnly: true, RequireSDK: true})
+		assert.IsErr[F](t, err, app.ErrMissingSDK)
+		assert.Equal[E](t, buf.String(), "* 1.19 (missing SDK)\n")
+	})
+
+	t.Run("list minor versions", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				link:  "/path/to/go1.21.6",
+				files: []string{"go1.21.3", "go1.21.6", "go1.19.13", "go1.19.2"},
+				calls: new([]string),
+			},
+			SDK: &spyFS{
+				dir:   "sdk",
+				files: []string{"go1.21.3/.unpacked-success", "go1.21.6/.unpacked-success", "go1.19.13/.unpacked-success", "go1.19.2/.unpacked-success"},
+				calls: new([]string),
+			},
+			Output: &buf,
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{Minor: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), ""+
+			"* 1.21.6 \n"+
+			"  1.20    (main)\n"+
+			"  1.19.13\n")
+	})
+
+	t.Run("list local versions (mixed GOBIN)", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				files: []string{"go1.18", "gopls", "go-tools/staticcheck", "go1.19/go", "go.tmp", ".unpacked-success", ".go1.17"},
+				calls: &steps,
+			},
+			SDK: &spyFS{
+				dir:   "sdk",
+				files: []string{"go1.18/.unpacked-success"},
+				calls: &steps,
+			},
+			Output: &buf,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, "\n"+buf.String(), `
+* 1.20 (main)
+  1.18
+`)
+	})
+
+	t.Run("list remote versions", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				link:  "/path/to/go1.18",
+				files: []string{"go1.18"},
+				calls: &steps,
+			},
+			SDK: &spyFS{
+				dir:   "sdk",
+				files: []string{"go1.18/.unpacked-success"},
+				calls: &steps,
+			},
+			Output: &buf,
+			Requester: httpSpy{
+				requests: &steps,
+				response: `[{"version":"1.20"},{"version":"1.19"},{"version":"1.18"}]`,
+			},
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{All: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, "\n"+buf.String(), `
+  tip  (not installed)
+  1.20 (main)
+  1.19 (not installed)
+* 1.18
+`)
+		assert.Equal[E](t, steps, []string{
+			`exec: go version`,                               // 1. read main version
+			`call: bin.Lstat("go")`,                          // 2. check go symlink
+			`call: bin.Readlink("go")`,                       // 3. read current version
+			`call: bin.ReadDir(".")`,                         // 4. read installed versions
+			`http: https://go.dev/dl/?mode=json&include=all`, // 5. get remote versions
+			`call: sdk.Stat("go1.18/.unpacked-success")`,     // 6. check 1.18 SDK
+		})
+	})
+
+	t.Run("list local versions (duplicates)", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{dir: "bin", link: "/path/to/go1.21.6", files: []string{"go1.21.6", "go1.21.5", "go1.19", "gotip"}, calls: new([]string)},
+			SDK: &spyFS{
+				dir:   "sdk",
+				files: []string{"go1.21.6/.unpacked-success", "go1.21.5/.unpacked-success", "go1.21.5/bin/go", "go1.19/.unpacked-success"},
+				calls: new([]string),
+			},
+			Output: &buf,
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{Duplicates: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, "\n"+buf.String(), `
+* 1.21.6 (1 B)
+  1.21.5 (2 B)
+
+The older patches can be removed with `+"`goversion rm <version>`"+`
+`)
+
+		buf.Reset()
+		recordCmds(&a, new([]string), "go version go1.19.2")
+		a.GoBin = &spyFS{dir: "bin", files: []string{"go1.21.6"}, calls: new([]string)}
+		err = a.List(context.Background(), app.ListOptions{Duplicates: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "No minor versions have multiple patches installed\n")
+	})
+
+	t.Run("list local versions (no main)", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", link: "/path/to/go1.18", files: []string{"go1.18", "go1.19"}, calls: new([]string)},
+			SDK:    &spyFS{dir: "sdk", files: []string{"go1.18/.unpacked-success", "go1.19/.unpacked-success"}, calls: new([]string)},
+			Output: &buf,
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{NoMain: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "  1.19\n* 1.18\n")
+
+		// the main version is shown if its binary is installed as well.
+		buf.Reset()
+		recordCmds(&a, new([]string), "go version go1.19")
+		err = a.List(context.Background(), app.ListOptions{NoMain: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "  1.19 (main)\n* 1.18\n")
+	})
+
+	t.Run("list local versions (active first)", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		state := &spyFS{dir: "state", calls: new([]string)}
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", link: "/path/to/go1.18", files: []string{"go1.18", "go1.19"}, calls: new([]string)},
+			SDK:    &spyFS{dir: "sdk", files: []string{"go1.18/.unpacked-success", "go1.19/.unpacked-success"}, calls: new([]string)},
+			State:  state,
+			Output: &buf,
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{ActiveFirst: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "* 1.18\n  1.20 (main)\n  1.19\n")
+
+		// the same, but enabled in the config.
+		buf.Reset()
+		state.contents = map[string]string{"config": "ls.active_first = true\n"}
+		err = a.List(context.Background(), app.ListOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "* 1.18\n  1.20 (main)\n  1.19\n")
+	})
+
+	t.Run("list remote versions (prefer installed)", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", files: []string{"go1.18"}, calls: new([]string)},
+			SDK:    &spyFS{dir: "sdk", files: []string{"go1.18/.unpacked-success"}, calls: new([]string)},
+			Output: &buf,
+			Requester: httpSpy{
+				requests: new([]string),
+				response: `[{"version":"1.20"},{"version":"1.19"},{"version":"1.18"}]`,
+			},
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{All: true, PreferInstalled: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, "\n"+buf.String(), `
+* 1.20 (main)
+  1.18
+  tip  (not installed)
+  1.19 (not installed)
+`)
+	})
+
+	t.Run("list remote versions (grouped)", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", link: "/path/to/go1.21.5", files: []string{"go1.21.5"}, calls: new([]string)},
+			SDK:    &spyFS{dir: "sdk", files: []string{"go1.21.5/.unpacked-success"}, calls: new([]string)},
+			Output: &buf,
+			Requester: httpSpy{
+				requests: new([]string),
+				response: `[{"version":"go1.22rc1"},{"version":"go1.21.6"},{"version":"go1.21.5"},{"version":"go1.20"}]`,
+			},
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{All: true, Grouped: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, "\n"+buf.String(), `
+tip:
+    tip     (not installed)
+1.22:
+    1.22rc1 (not installed)
+1.21:
+    1.21.6  (not installed)
+  * 1.21.5 `+`
+1.20:
+    1.20    (main)
+`)
+	})
+
+	t.Run("list remote versions (security)", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", calls: new([]string)},
+			SDK:    &spyFS{dir: "sdk", calls: new([]string)},
+			Output: &buf,
+			Requester: httpSpy{
+				requests: new([]string),
+				response: `[{"version":"go1.22.1"},{"version":"go1.22.0"},{"version":"go1.21.8"},{"version":"go1.20.14"}]`,
+			},
+		}
+		recordCmds(&a, new([]string), "go version go1.20.14")
+
+		err := a.List(context.Background(), app.ListOptions{Security: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, "\n"+buf.String(), `
+  tip     (not installed)
+  1.22.1  (not installed, latest — recommended)
+  1.22.0  (not installed)
+  1.21.8  (not installed, latest — recommended)
+* 1.20.14 (main)
+`)
+	})
+
+	t.Run("list remote versions (prerelease)", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", calls: new([]string)},
+			SDK:    &spyFS{dir: "sdk", calls: new([]string)},
+			Output: &buf,
+			Requester: httpSpy{
+				requests: new([]string),
+				response: `[{"version":"go1.22rc2"},{"version":"go1.22rc1"},{"version":"go1.21.6"},{"version":"go1.21beta1"}]`,
+			},
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{All: true, Prerelease: "rc"})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "  1.22rc2 (not installed)\n  1.22rc1 (not installed)\n")
+
+		buf.Reset()
+		err = a.List(context.Background(), app.ListOptions{All: true, Prerelease: "beta"})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "  1.21beta1 (not installed)\n")
+
+		err = a.List(context.Background(), app.ListOptions{All: true, Prerelease: "alpha"})
+		assert.Equal[E](t, err.Error(), `unknown prerelease kind "alpha" (supported: beta, rc)`)
+	})
+
+	t.Run("list remote versions (platform)", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", calls: new([]string)},
+			SDK:    &spyFS{dir: "sdk", calls: new([]string)},
+			Output: &buf,
+			Requester: httpSpy{
+				requests: new([]string),
+				response: `[
+{"version":"go1.22.0","files":[{"os":"","arch":"","kind":"source"},{"os":"windows","arch":"arm64","kind":"archive"},{"os":"windows","arch":"arm64","kind":"installer"}]},
+{"version":"go1.16.15","files":[{"os":"","arch":"","kind":"source"},{"os":"windows","arch":"amd64","kind":"archive"},{"os":"linux","arch":"amd64","kind":"archive"}]},
+{"version":"go1.16","files":[{"os":"windows","arch":"arm64","kind":"installer"}]}
+]`,
+			},
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{OS: "windows", Arch: "arm64"})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, "\n"+buf.String(), `
+  tip    (not installed)
+  1.22.0 (not installed)
+`)
+
+		buf.Reset()
+		err = a.List(context.Background(), app.ListOptions{OS: "windows", Arch: "arm64", Kind: "installer"})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, "\n"+buf.String(), `
+  tip    (not installed)
+  1.22.0 (not installed)
+  1.16   (not installed)
+`)
+
+		err = a.List(context.Background(), app.ListOptions{Kind: "msi"})
+		assert.Equal[E](t, err.Error(), `unknown file kind "msi" (supported: archive, installer, source)`)
+	})
+
+	t.Run("list remote versions (HTML fallback)", func(t *testing.T) {
+		var steps []string
+		var buf, errs bytes.Buffer
+
+		a := app.App{
+			GoBin:        &spyFS{dir: "bin", calls: new([]string)},
+			SDK:          &spyFS{dir: "sdk", calls: new([]string)},
+			Output:       &buf,
+			Errors:       &errs,
+			HTMLFallback: true,
+			Requester: httpSpy{
+				requests: &steps,
+				// the same response for both endpoints: not a valid JSON, but a valid HTML page.
+				response: `<html>
+<div class="toggleVisible" id="go1.22.1"><a href="/dl/go1.22.1.linux-amd64.tar.gz">...</a></div>
+<div class="toggle" id="go1.21.8"><a href="/dl/go1.21.8.linux-amd64.tar.gz">...</a></div>
+<div class="toggle" id="go1.21.8"></div>
+<div class="toggle" id="archive"></div>
+</html>`,
+			},
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{All: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, "\n"+buf.String(), `
+  tip    (not installed)
+  1.22.1 (not installed)
+  1.21.8 (not installed)
+`)
+		assert.Equal[E](t, errs.String(), "Warning: falling back to the go.dev HTML page: invalid character '<' looking for beginning of value\n")
+		assert.Equal[E](t, steps, []string{
+			`http: https://go.dev/dl/?mode=json&include=all`, // 1. get remote versions (fails)
+			`http: https://go.dev/dl/`,                       // 2. scrape remote versions
+		})
+	})
+
+	t.Run("list remote versions (timed out)", func(t *testing.T) {
+		a := app.App{
+			GoBin:     &spyFS{dir: "bin", calls: new([]string)},
+			SDK:       &spyFS{dir: "sdk", calls: new([]string)},
+			Output:    io.Discard,
+			Timeout:   time.Minute,
+			Requester: httpSpy{requests: new([]string), err: context.DeadlineExceeded},
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{All: true})
+		assert.IsErr[F](t, err, context.DeadlineExceeded)
+		assert.Equal[E](t, err.Error(), "timed out after 1m0s: context deadline exceeded")
+
+		a.Timeout = 0
+		err = a.List(context.Background(), app.ListOptions{All: true})
+		assert.IsErr[F](t, err, context.DeadlineExceeded)
+		assert.Equal[E](t, err.Error(), "timed out: context deadline exceeded")
+	})
+
+	t.Run("list remote versions (empty list)", func(t *testing.T) {
+		a := app.App{
+			GoBin:     &spyFS{dir: "bin", calls: new([]string)},
+			SDK:       &spyFS{dir: "sdk", calls: new([]string)},
+			Output:    io.Discard,
+			Requester: httpSpy{requests: new([]string), response: `[]`},
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{All: true})
+		assert.IsErr[F](t, err, app.ErrNoVersions)
+
+		err = a.Use(context.Background(), "1.22@latest", app.UseOptions{})
+		assert.IsErr[F](t, err, app.ErrNoVersions)
+	})
+
+	t.Run("list remote versions (user agent)", func(t *testing.T) {
+		var userAgent string
+
+		a := app.App{
+			GoBin:     &spyFS{dir: "bin", calls: new([]string)},
+			SDK:       &spyFS{dir: "sdk", calls: new([]string)},
+			Output:    io.Discard,
+			Version:   "v1.2.3",
+			Requester: httpSpy{requests: new([]string), response: `[{"version":"go1.21.6"}]`, userAgent: &userAgent},
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{All: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, userAgent, "goversion/v1.2.3")
+	})
+
+	t.Run("list remote versions (total timeout)", func(t *testing.T) {
+		cause := fmt.Errorf("timed out after 10m0s in total: %w", context.DeadlineExceeded)
+		ctx, cancel := context.WithDeadlineCause(context.Background(), time.Time{}, cause)
+		defer cancel()
+
+		a := app.App{
+			GoBin:     &spyFS{dir: "bin", calls: new([]string)},
+			SDK:       &spyFS{dir: "sdk", calls: new([]string)},
+			Output:    io.Discard,
+			Timeout:   time.Minute,
+			Requester: httpSpy{requests: new([]string), err: context.DeadlineExceeded},
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(ctx, app.ListOptions{All: true})
+		assert.IsErr[F](t, err, context.DeadlineExceeded)
+		assert.Equal[E](t, err.Error(), "timed out after 10m0s in total: context deadline exceeded")
+	})
+
+	t.Run("list remote versions (network disabled)", func(t *testing.T) {
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", calls: new([]string)},
+			SDK:    &spyFS{dir: "sdk", calls: new([]string)},
+			Output: io.Discard,
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{All: true})
+		assert.IsErr[F](t, err, app.ErrNetworkDisabled)
+		assert.Equal[E](t, err.Error(), "fetching https://go.dev/dl/?mode=json&include=all: network disabled")
+	})
+
+	t.Run("list remote versions (interrupted)", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel() // e.g. Ctrl+C.
+
+		a := app.App{
+			GoBin:     &spyFS{dir: "bin", calls: new([]string)},
+			SDK:       &spyFS{dir: "sdk", calls: new([]string)},
+			Output:    io.Discard,
+			Requester: httpSpy{requests: new([]string), err: errors.New("connection reset")},
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.List(ctx, app.ListOptions{All: true})
+		assert.IsErr[F](t, err, context.Canceled)
+		assert.Equal[E](t, err.Error(), "interrupted: context canceled")
+	})
+
+	t.Run("list porcelain", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				link:  "/path/to/go1.18",
+				files: []string{"go1.18", "go1.19"},
+				calls: &steps,
+			},
+			SDK: &spyFS{
+				dir:   "sdk",
+				files: []string{"go1.18/.unpacked-success"}, // 1.19 SDK is missing.
+				calls: &steps,
+			},
+			Output: &buf,
+			Requester: httpSpy{
+				requests: &steps,
+				response: `[{"version":"1.20"},{"version":"1.19"},{"version":"1.18"},{"version":"1.17"}]`,
+			},
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.List(context.Background(), app.ListOptions{All: true, Porcelain: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, "\n"+buf.String(), `
+tip	available
+1.20	main
+1.19	missing-sdk
+1.18	active
+1.17	available
+`)
+	})
+}
+
+func TestApp_List_malformed(t *testing.T) {
+	var steps []string
+	var buf, errs bytes.Buffer
+
+	a := app.App{
+		GoBin:  &spyFS{dir: "bin", calls: &steps},
+		SDK:    &spyFS{dir: "sdk", calls: &steps},
+		Output: &buf,
+		Errors: &errs,
+		Requester: httpSpy{
+			requests: &steps,
+			response: `[{"version":"go1.21.1"},{"version":"1.21.0"},{"version":"latest"},{"version":""}]`,
+		},
+	}
+	recordCmds(&a, &steps, "go version go1.21.1")
+
+	err := a.List(context.Background(), app.ListOptions{All: true, Porcelain: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "tip\tavailable\n1.21.1\tactive\n1.21.0\tavailable\n")
+	assert.Equal[E](t, errs.String(), "Warning: skipping malformed version \"latest\"\nWarning: skipping malformed version \"\"\n")
+}
+
+func TestApp_List_json(t *testing.T) {
+	var steps []string
+	var buf bytes.Buffer
+
+	a := app.App{
+		GoBin: &spyFS{
+			dir:   "bin",
+			link:  "/path/to/go1.18",
+			files: []string{"go1.18"},
+			calls: &steps,
+		},
+		SDK: &spyFS{
+			dir:   "sdk",
+			files: []string{"go1.18/.unpacked-success"},
+			calls: &steps,
+		},
+		Output: &buf,
+	}
+	recordCmds(&a, &steps, "go version go1.20")
+
+	err := a.List(context.Background(), app.ListOptions{JSON: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), `{
+  "schema": 1,
+  "versions": [
+    {
+      "version": "1.20",
+      "status": "main",
+      "channel": "stable",
+      "current": false
+    },
+    {
+      "version": "1.18",
+      "status": "active",
+      "channel": "stable",
+      "current": true,
+      "sdk_path": "/sdk/go1.18"
+    }
+  ]
+}
+`)
+	assert.Equal[E](t, slices.Contains(steps, `call: sdk.ReadDir("go1.18")`), false)
+
+	buf.Reset()
+	err = a.List(context.Background(), app.ListOptions{JSON: true, Sizes: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), `{
+  "schema": 1,
+  "versions": [
+    {
+      "version": "1.20",
+      "status": "main",
+      "channel": "stable",
+      "current": false
+    },
+    {
+      "version": "1.18",
+      "status": "active",
+      "channel": "stable",
+      "current": true,
+      "sdk_path": "/sdk/go1.18",
+      "size": 1
+    }
+  ]
+}
+`)
+
+	err = a.List(context.Background(), app.ListOptions{Sizes: true})
+	assert.Equal[E](t, err.Error(), "-sizes can only be used with -json")
+}
+
+func TestApp_List_outdated(t *testing.T) {
+	var steps []string
+	var buf bytes.Buffer
+
+	a := app.App{
+		GoBin: &spyFS{
+			dir:   "bin",
+			link:  "/path/to/go1.21.5",
+			files: []string{"go1.21.5", "go1.19.13", "gotip"},
+			calls: &steps,
+		},
+		SDK: &spyFS{
+			dir:   "sdk",
+			files: []string{"go1.21.5/.unpacked-success", "go1.19.13/.unpacked-success", "gotip/bin/go"},
+			calls: &steps,
+		},
+		Output: &buf,
+		Requester: httpSpy{
+			requests: &steps,
+			response: `[{"version":"go1.22rc1"},{"version":"go1.21.7"},{"version":"go1.21.6"},{"version":"go1.21.5"},{"version":"go1.20.2"},{"version":"go1.20.1"},{"version":"go1.19.13"}]`,
+		},
+	}
+	recordCmds(&a, &steps, "go version go1.20.1")
+
+	err := a.List(context.Background(), app.ListOptions{Outdated: true})
+	assert.IsErr[F](t, err, app.ErrUpdateAvailable)
+	assert.Equal[E](t, buf.String(), ""+
+		"  tip    \n"+
+		"* 1.21.5  (1.21.7 available, 2 patches behind)\n"+
+		"  1.20.1  (main, 1.20.2 available, 1 patch behind)\n"+
+		"  1.19.13\n")
+}
+
+func TestApp_List_upToDate(t *testing.T) {
+	a := app.App{
+		GoBin:  &spyFS{dir: "bin", files: []string{"go1.21.6"}, calls: new([]string)},
+		SDK:    &spyFS{dir: "sdk", files: []string{"go1.21.6/.unpacked-success"}, calls: new([]string)},
+		Output: io.Discard,
+		Requester: httpSpy{
+			requests: new([]string),
+			response: `[{"version":"go1.21.6"},{"version":"go1.20.2"}]`,
+		},
+	}
+	recordCmds(&a, new([]string), "go version go1.20.2")
+
+	err := a.List(context.Background(), app.ListOptions{Outdated: true})
+	assert.NoErr[F](t, err)
+}
+
+func TestApp_List_sdkPath(t *testing.T) {
+	var steps []string
+	var buf bytes.Buffer
+
+	a := app.App{
+		GoBin: &spyFS{
+			dir:   "bin",
+			link:  "/path/to/go1.18",
+			files: []string{"go1.18", "gotip"},
+			calls: &steps,
+		},
+		SDK: &spyFS{
+			dir:   "sdk",
+			files: []string{"go1.18/.unpacked-success", "gotip/bin/go"},
+			calls: &steps,
+		},
+		Output: &buf,
+		Requester: httpSpy{
+			requests: &steps,
+			response: `[{"version":"go1.20"},{"version":"go1.19"},{"version":"go1.18"}]`,
+		},
+	}
+	recordCmds(&a, &steps, "go version go1.20")
+
+	err := a.List(context.Background(), app.ListOptions{All: true, SDKPath: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), ""+
+		"  tip  /sdk/gotip \n"+
+		"  1.20             (main)\n"+
+		"  1.19             (not installed)\n"+
+		"* 1.18 /sdk/go1.18\n")
+}
+
+func TestApp_List_fromStdin(t *testing.T) {
+	var steps []string
+	var buf bytes.Buffer
+
+	a := app.App{
+		GoBin:  &spyFS{dir: "bin", calls: &steps},
+		SDK:    &spyFS{dir: "sdk", calls: &steps},
+		Input:  strings.NewReader(`[{"version":"go1.21.1"},{"version":"go1.20"}]`),
+		Output: &buf,
+	}
+	recordCmds(&a, &steps, "go version go1.20")
+
+	err := a.List(context.Background(), app.ListOptions{FromStdin: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, "\n"+buf.String(), `
+  tip    (not installed)
+  1.21.1 (not installed)
+* 1.20   (main)
+`)
+	assert.Equal[E](t, steps, []string{
+		`exec: go version`,       // 1. read main version
+		`call: bin.Lstat("go")`,  // 2. check go symlink
+		`call: bin.ReadDir(".")`, // 3. read installed versions
+	})
+}
+
+func TestApp_List_fromStdinUnsorted(t *testing.T) {
+	var buf bytes.Buffer
+
+	a := app.App{
+		GoBin:  &spyFS{dir: "bin", calls: new([]string)},
+		SDK:    &spyFS{dir: "sdk", calls: new([]string)},
+		Input:  strings.NewReader(`[{"version":"go1.20.1"},{"version":"go1.21.0"},{"version":"go1.20.2"}]`),
+		Output: &buf,
+	}
+	recordCmds(&a, new([]string), "go version go1.20")
+
+	err := a.List(context.Background(), app.ListOptions{FromStdin: true, Minor: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, "\n"+buf.String(), `
+  tip    (not installed)
+  1.21.0 (not installed)
+  1.20.2 (not installed)
+`)
+
+	buf.Reset()
+	a.Input = strings.NewReader(`[{"version":"go1.20.1"},{"version":"go1.21rc1"},{"version":"go1.21.1"},{"version":"go1.20.2"}]`)
+	err = a.List(context.Background(), app.ListOptions{FromStdin: true, Only: "latest"})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, "\n"+buf.String(), `
+  tip    (not installed)
+  1.21.1 (not installed)
+  1.20.2 (not installed)
+`)
+}
+
+func TestApp_List_since(t *testing.T) {
+	var steps []string
+	var buf bytes.Buffer
+
+	a := app.App{
+		GoBin:  &spyFS{dir: "bin", calls: &steps},
+		SDK:    &spyFS{dir: "sdk", calls: &steps},
+		Output: &buf,
+		Requester: httpSpy{
+			requests: &steps,
+			response: `[{"version":"1.21.1"},{"version":"1.21.0"},{"version":"1.21rc1"},{"version":"1.20.2"},{"version":"1.20.1"},{"version":"1.20"},{"version":"1.19.1"}]`,
+		},
+	}
+	recordCmds(&a, &steps, "go version go1.19.1")
+
+	err := a.List(context.Background(), app.ListOptions{All: true, Only: "latest", Since: "1.20"})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, "\n"+buf.String(), `
+  tip    (not installed)
+  1.21.1 (not installed)
+  1.20.2 (not installed)
+`)
+
+	err = a.List(context.Background(), app.ListOptions{Since: "1.x"})
+	assert.Equal[E](t, err.Error(), `malformed version "1.x"`)
+}
+
+func TestApp_List_until(t *testing.T) {
+	var steps []string
+	var buf bytes.Buffer
+
+	a := app.App{
+		GoBin:  &spyFS{dir: "bin", calls: &steps},
+		SDK:    &spyFS{dir: "sdk", calls: &steps},
+		Output: &buf,
+		Requester: httpSpy{
+			requests: &steps,
+			response: `[{"version":"1.21.1"},{"version":"1.21rc1"},{"version":"1.20.2"},{"version":"1.20"},{"version":"1.19.1"},{"version":"1.18"}]`,
+		},
+	}
+	recordCmds(&a, &steps, "go version go1.19.1")
+
+	err := a.List(context.Background(), app.ListOptions{All: true, Since: "1.18", Until: "1.20"})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, "\n"+buf.String(), `
+  1.20   (not installed)
+* 1.19.1 (main)
+`)
+}
+
+func TestApp_List_compare(t *testing.T) {
+	var buf bytes.Buffer
+
+	a := app.App{
+		GoBin:  &spyFS{dir: "bin", calls: new([]string)},
+		SDK:    &spyFS{dir: "sdk", calls: new([]string)},
+		Output: &buf,
+		Requester: httpSpy{
+			requests: new([]string),
+			response: `[{"version":"1.21.3"},{"version":"1.21.2"},{"version":"1.21rc1"},{"version":"1.20.2"}]`,
+		},
+	}
+	recordCmds(&a, new([]string), "go version go1.21.2")
+
+	err := a.List(context.Background(), app.ListOptions{All: true, Only: "1.21", Compare: "1.21.2"})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, "\n"+buf.String(), `
+  1.21.3  (not installed, newer)
+* 1.21.2  (main, same)
+  1.21rc1 (not installed, older)
+`)
+
+	buf.Reset()
+	err = a.List(context.Background(), app.ListOptions{JSON: true, Compare: "1.22"})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, strings.Contains(buf.String(), `"compare": "older"`), true)
+
+	err = a.List(context.Background(), app.ListOptions{Compare: "1.x"})
+	assert.Equal[E](t, err.Error(), `malformed version "1.x"`)
+}
+
+func TestApp_History(t *testing.T) {
+	var steps []string
+	var buf bytes.Buffer
+
+	history := "garbage\n"
+	for i := range 25 {
+		history += fmt.Sprintf("2024-01-%02dT00:00:00Z\t1.%d\n", i+1, i)
+	}
+
+	a := app.App{
+		GoBin: &spyFS{
+			dir:   "bin",
+			link:  "/path/to/go1.18",
+			files: []string{"go1.18"},
+			calls: &steps,
+		},
+		SDK: &spyFS{dir: "sdk", calls: &steps},
+		State: &spyFS{
+			dir:      "state",
+			contents: map[string]string{"history": history},
+			calls:    &steps,
+		},
+		Output: &buf,
+		Now:    now,
+	}
+	recordCmds(&a, &steps, "go version go1.20")
+
+	err := a.Use(context.Background(), "main", app.UseOptions{})
+	assert.NoErr[F](t, err)
+
+	buf.Reset()
+	err = a.History()
+	assert.NoErr[F](t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal[E](t, len(lines), 20)
+	assert.Equal[E](t, lines[0], "2024-01-01 00:00:00 1.20")
+	assert.Equal[E](t, lines[1], "2024-01-25 00:00:00 1.24")
+	assert.Equal[E](t, lines[19], "2024-01-07 00:00:00 1.6")
+}
+
+func TestApp_ConfigDir(t *testing.T) {
+	var buf bytes.Buffer
+
+	a := app.App{
+		State:  &spyFS{dir: "state", calls: new([]string)},
+		Output: &buf,
+	}
+
+	err := a.ConfigDir()
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "/state\n")
+
+	a.State = nil
+	err = a.ConfigDir()
+	assert.Equal[E](t, err.Error(), "no state directory is configured")
+}
+
+func TestApp_SelfUpdate(t *testing.T) {
+	t.Run("update available", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{Output: &buf}
+		recordCmds(&a, &steps, `{"Path":"go-simpler.org/goversion","Version":"v0.10.0"}`)
+
+		err := a.SelfUpdate(context.Background(), "0.9.1", false)
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "Updating goversion 0.9.1 -> 0.10.0 ...\nUpdated goversion to 0.10.0\n")
+		assert.Equal[E](t, steps, []string{
+			`exec: go list -m -json go-simpler.org/goversion@latest`, // 1. get latest release
+			`exec: go install go-simpler.org/goversion@v0.10.0`,      // 2. install latest release
+		})
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{Output: &buf}
+		recordCmds(&a, &steps, `{"Path":"go-simpler.org/goversion","Version":"v0.10.0"}`)
+
+		err := a.SelfUpdate(context.Background(), "0.10.0", false)
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "goversion 0.10.0 is up to date\n")
+		assert.Equal[E](t, steps, []string{
+			`exec: go list -m -json go-simpler.org/goversion@latest`, // 1. get latest release
+		})
+	})
+}
+
+func TestApp_SelfUpdate_check(t *testing.T) {
+	t.Run("update available", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{Output: &buf}
+		recordCmds(&a, &steps, `{"Path":"go-simpler.org/goversion","Version":"v0.10.0"}`)
+
+		err := a.SelfUpdate(context.Background(), "0.9.1", true)
+		assert.IsErr[F](t, err, app.ErrUpdateAvailable)
+		assert.Equal[E](t, buf.String(), "goversion 0.10.0 is available (current: 0.9.1)\n")
+		assert.Equal[E](t, steps, []string{
+			`exec: go list -m -json go-simpler.org/goversion@latest`, // 1. get latest release
 		})
 	})
 
-	t.Run("switch to current version", func(t *testing.T) {
+	t.Run("development build", func(t *testing.T) {
+		var steps []string
+		var buf bytes.Buffer
+
+		a := app.App{Output: &buf}
+		recordCmds(&a, &steps, "")
+
+		err := a.SelfUpdate(context.Background(), "dev", true)
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "goversion dev is a development build\n")
+		assert.Equal[E](t, len(steps), 0)
+	})
+}
+
+func TestApp_Info(t *testing.T) {
+	t.Run("installed version", func(t *testing.T) {
 		var steps []string
 		var buf bytes.Buffer
 
-		app := app.App{
-			GoBin: spyFS{
+		a := app.App{
+			GoBin: &spyFS{
 				dir:   "bin",
 				link:  "/path/to/go1.18",
 				files: []string{"go1.18"},
 				calls: &steps,
 			},
-			SDK: spyFS{
+			SDK: &spyFS{
 				dir:   "sdk",
-				files: []string{"go1.18/.unpacked-success"},
+				files: []string{"go1.18/.unpacked-success", "go1.18/bin/go", "go1.18/bin/gofmt"},
 				calls: &steps,
 			},
 			Output: &buf,
+			Requester: httpSpy{
+				requests: &steps,
+				response: `[{"version":"go1.20"},{"version":"go1.18.10"},{"version":"go1.18"}]`,
+			},
 		}
-		recordCmds(&app, &steps, "go version go1.20")
+		recordCmds(&a, &steps, "go version go1.20")
 
-		err := app.Use(context.Background(), "1.18")
+		err := a.Info(context.Background(), "1.18", app.InfoOptions{Outdated: true})
 		assert.NoErr[F](t, err)
-		assert.Equal[E](t, buf.String(), "1.18 is already in use\n")
+		assert.Equal[E](t, "\n"+buf.String(), `
+Version:  1.18
+Status:   active
+SDK path: /sdk/go1.18
+SDK size: 3 B
+Update:   1.18.10
+`)
 		assert.Equal[E](t, steps, []string{
-			`exec: go version`,         // 1. read main version
-			`call: bin.Readlink("go")`, // 2. read current version
-			`call: bin.ReadDir(".")`,   // 3. read installed versions
+			`exec: go version`,                               // 1. read main version
+			`call: bin.Lstat("go")`,                          // 2. check go symlink
+			`call: bin.Readlink("go")`,                       // 3. read current version
+			`call: bin.ReadDir(".")`,                         // 4. read installed versions
+			`call: sdk.Stat("go1.18/.unpacked-success")`,     // 5. check 1.18 SDK
+			`call: sdk.Stat("go1.18")`,                       // 6. walk 1.18 SDK
+			`call: sdk.ReadDir("go1.18")`,                    // 7. ...
+			`call: sdk.ReadDir("go1.18/bin")`,                // 8. ...
+			`http: https://go.dev/dl/?mode=json&include=all`, // 9. get remote versions
 		})
 	})
 
-	t.Run("switch to main version", func(t *testing.T) {
-		var steps []string
+	t.Run("not installed version (JSON)", func(t *testing.T) {
 		var buf bytes.Buffer
 
-		app := app.App{
-			GoBin: spyFS{
+		a := app.App{
+			GoBin:  &spyFS{dir: "bin", calls: new([]string)},
+			SDK:    &spyFS{dir: "sdk", calls: new([]string)},
+			Output: &buf,
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.Info(context.Background(), "1.21.6", app.InfoOptions{JSON: true})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, "\n"+buf.String(), `
+{
+  "schema": 1,
+  "version": "1.21.6",
+  "status": "available",
+  "channel": "stable",
+  "current": false,
+  "installed": false
+}
+`)
+	})
+}
+
+func TestApp_Remove(t *testing.T) {
+	t.Run("remove existing version", func(t *testing.T) {
+		var steps []string
+
+		a := app.App{
+			GoBin: &spyFS{
 				dir:   "bin",
 				link:  "/path/to/go1.18",
 				files: []string{"go1.18"},
 				calls: &steps,
 			},
-			SDK: spyFS{
+			SDK: &spyFS{
 				dir:   "sdk",
 				files: []string{"go1.18/.unpacked-success"},
 				calls: &steps,
 			},
-			Output: &buf,
+			State:  &spyFS{dir: "state", calls: &steps},
+			Output: io.Discard,
 		}
-		recordCmds(&app, &steps, "go version go1.20")
+		recordCmds(&a, &steps, "go version go1.20")
 
-		err := app.Use(context.Background(), "main")
+		err := a.Remove(context.Background(), "1.18", app.RemoveOptions{})
 		assert.NoErr[F](t, err)
-		assert.Equal[E](t, buf.String(), "Switched to 1.20 (main)\n")
 		assert.Equal[E](t, steps, []string{
-			`exec: go version`,         // 1. read main version
-			`call: bin.Readlink("go")`, // 2. read current version
-			`call: bin.ReadDir(".")`,   // 3. read installed versions
-			`call: bin.Remove("go")`,   // 4. remove symlink (switch to main)
+			`exec: go version`,                           // 1. read main version
+			`call: bin.Lstat("go")`,                      // 2. check go symlink
+			`call: bin.Readlink("go")`,                   // 3. read current version
+			`call: bin.ReadDir(".")`,                     // 4. read installed versions
+			`call: state.ReadFile("pins")`,               // 5. read pinned versions
+			`call: bin.Remove("go")`,                     // 6. remove symlink (switch to main)
+			`call: sdk.Stat("go1.18/.unpacked-success")`, // 7. check 1.18 SDK
+			`call: bin.Remove("go1.18")`,                 // 8. remove 1.18 binary
+			`call: sdk.RemoveAll("go1.18")`,              // 9. remove 1.18 SDK
+			`call: state.ReadFile("previous")`,           // 10. read previous version
 		})
 	})
-}
 
-func TestApp_List(t *testing.T) {
-	t.Run("list local versions", func(t *testing.T) {
+	t.Run("remove tip", func(t *testing.T) {
 		var steps []string
 		var buf bytes.Buffer
 
-		app := app.App{
-			GoBin: spyFS{
-				dir:   "bin",
-				link:  "/path/to/go1.18",
-				files: []string{"go1.18", "go1.19"},
-				calls: &steps,
-			},
-			SDK: spyFS{
+		a := app.App{
+			GoBin: &spyFS{dir: "bin", link: "/path/to/go1.18", files: []string{"go1.18", "gotip"}, calls: &steps},
+			SDK: &spyFS{
 				dir:   "sdk",
-				files: []string{"go1.18/.unpacked-success"}, // 1.19 SDK is missing.
+				files: []string{"go1.18/.unpacked-success", "gotip/bin/go", "gotip/src/go.mod"},
 				calls: &steps,
 			},
+			State:  &spyFS{dir: "state", contents: map[string]string{"tip-ref": "12345\n"}, calls: &steps},
 			Output: &buf,
 		}
-		recordCmds(&app, &steps, "go version go1.20")
+		recordCmds(&a, &steps, "go version go1.20")
 
-		err := app.List(context.Background(), false, "")
+		err := a.Remove(context.Background(), "tip", app.RemoveOptions{})
 		assert.NoErr[F](t, err)
-		assert.Equal[E](t, "\n"+buf.String(), `
-  1.20 (main)
-  1.19 (missing SDK)
-* 1.18
-`)
+		assert.Equal[E](t, buf.String(), "Removed tip (freed 2 B)\n")
 		assert.Equal[E](t, steps, []string{
-			`exec: go version`,                           // 1. read main version
-			`call: bin.Readlink("go")`,                   // 2. read current version
-			`call: bin.ReadDir(".")`,                     // 3. read installed versions
-			`call: sdk.Stat("go1.19/.unpacked-success")`, // 4. check 1.19 SDK
-			`call: sdk.Stat("go1.18/.unpacked-success")`, // 5. check 1.18 SDK
+			`exec: go version`,                 // 1. read main version
+			`call: bin.Lstat("go")`,            // 2. check go symlink
+			`call: bin.Readlink("go")`,         // 3. read current version
+			`call: bin.ReadDir(".")`,           // 4. read installed versions
+			`call: state.ReadFile("pins")`,     // 5. read pinned versions
+			`call: sdk.Stat("gotip/bin/go")`,   // 6. check tip SDK
+			`call: sdk.Stat("gotip")`,          // 7. measure tip checkout
+			`call: sdk.ReadDir("gotip")`,       // 8. ...
+			`call: sdk.ReadDir("gotip/bin")`,   // 9. ...
+			`call: sdk.ReadDir("gotip/src")`,   // 10. ...
+			`call: bin.Remove("gotip")`,        // 11. remove gotip binary
+			`call: sdk.RemoveAll("gotip")`,     // 12. remove tip checkout
+			`call: state.ReadFile("previous")`, // 13. read previous version
+			`call: state.Remove("tip-ref")`,    // 14. forget tip ref
 		})
 	})
 
-	t.Run("list remote versions", func(t *testing.T) {
+	t.Run("remove previous version", func(t *testing.T) {
 		var steps []string
-		var buf bytes.Buffer
 
-		app := app.App{
-			GoBin: spyFS{
+		state := &spyFS{
+			dir:      "state",
+			contents: map[string]string{"previous": "1.18\n"},
+			calls:    &steps,
+		}
+		a := app.App{
+			GoBin: &spyFS{
 				dir:   "bin",
-				link:  "/path/to/go1.18",
 				files: []string{"go1.18"},
 				calls: &steps,
 			},
-			SDK: spyFS{
-				dir:   "sdk",
-				files: []string{"go1.18/.unpacked-success"},
-				calls: &steps,
+			SDK:    &spyFS{dir: "sdk", calls: &steps},
+			State:  state,
+			Output: io.Discard,
+		}
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Remove(context.Background(), "1.18", app.RemoveOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, steps[len(steps)-2:], []string{
+			`call: state.ReadFile("previous")`, // 1. read previous version
+			`call: state.Remove("previous")`,   // 2. forget previous version
+		})
+		_, ok := state.contents["previous"]
+		assert.Equal[E](t, ok, false)
+	})
+
+	t.Run("remove version without SDK", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				files: []string{"go1.19"},
+				calls: new([]string),
 			},
+			SDK:    &spyFS{dir: "sdk", calls: new([]string)}, // 1.19 SDK was deleted manually.
+			State:  &spyFS{dir: "state", calls: new([]string)},
 			Output: &buf,
-			Requester: httpSpy{
-				requests: &steps,
-				response: `[{"version":"1.20"},{"version":"1.19"},{"version":"1.18"}]`,
+		}
+		recordCmds(&a, new([]string), "go version go1.20")
+
+		err := a.Remove(context.Background(), "1.19", app.RemoveOptions{})
+		assert.NoErr[F](t, err)
+		assert.Equal[E](t, buf.String(), "Removed 1.19 (binary only; SDK was already absent)\n")
+	})
+
+	t.Run("remove pinned version", func(t *testing.T) {
+		var steps []string
+
+		a := app.App{
+			GoBin: &spyFS{
+				dir:   "bin",
+				files: []string{"go1.18"},
+				calls: &steps,
+			},
+			SDK: &spyFS{dir: "sdk", calls: &steps},
+			State: &spyFS{
+				dir:      "state",
+				contents: map[string]string{"pins": "1.18\n"},
+				calls:    &steps,
 			},
+			Output: io.Discard,
 		}
-		recordCmds(&app, &steps, "go version go1.20")
+		recordCmds(&a, &steps, "go version go1.20")
+
+		err := a.Remove(context.Background(), "1.18", app.RemoveOptions{})
+		assert.Equal[F](t, err.Error(), "1.18 is pinned; unpin it or use -force")
 
-		err := app.List(context.Background(), true, "")
+		steps = nil
+		err = a.Remove(context.Background(), "1.18", app.RemoveOptions{Force: true})
 		assert.NoErr[F](t, err)
-		assert.Equal[E](t, "\n"+buf.String(), `
-  tip  (not installed)
-  1.20 (main)
-  1.19 (not installed)
-* 1.18
-`)
 		assert.Equal[E](t, steps, []string{
-			`exec: go version`,                               // 1. read main version
-			`call: bin.Readlink("go")`,                       // 2. read current version
-			`call: bin.ReadDir(".")`,                         // 3. read installed versions
-			`http: https://go.dev/dl/?mode=json&include=all`, // 4. get remote versions
-			`call: sdk.Stat("go1.18/.unpacked-success")`,     // 5. check 1.18 SDK
+			`exec: go version`,                           // 1. read main version
+			`call: bin.Lstat("go")`,                      // 2. check go symlink
+			`call: bin.ReadDir(".")`,                     // 3. read installed versions
+			`call: sdk.Stat("go1.18/.unpacked-success")`, // 4. check 1.18 SDK
+			`call: bin.Remove("go1.18")`,                 // 5. remove 1.18 binary
+			`call: sdk.RemoveAll("go1.18")`,              // 6. remove 1.18 SDK
+			`call: state.ReadFile("previous")`,           // 7. read previous version
 		})
 	})
-}
 
-func TestApp_Remove(t *testing.T) {
-	t.Run("remove existing version", func(t *testing.T) {
+	t.Run("remove current version (no switch)", func(t *testing.T) {
 		var steps []string
 
-		app := app.App{
-			GoBin: spyFS{
+		a := app.App{
+			GoBin: &spyFS{
 				dir:   "bin",
 				link:  "/path/to/go1.18",
 				files: []string{"go1.18"},
 				calls: &steps,
 			},
-			SDK: spyFS{
-				dir:   "sdk",
-				files: []string{"go1.18/.unpacked-success"},
-				calls: &steps,
-			},
+			SDK:    &spyFS{dir: "sdk", calls: &steps},
+			State:  &spyFS{dir: "state", calls: &steps},
 			Output: io.Discard,
 		}
-		recordCmds(&app, &steps, "go version go1.20")
+		recordCmds(&a, &steps, "go version go1.20")
 
-		err := app.Remove(context.Background(), "1.18")
-		assert.NoErr[F](t, err)
+		err := a.Remove(context.Background(), "1.18", app.RemoveOptions{NoSwitch: true})
+		assert.Equal[F](t, err.Error(), "1.18 is in use; switch to another version first")
 		assert.Equal[E](t, steps, []string{
-			`exec: go version`,              // 1. read main version
-			`call: bin.Readlink("go")`,      // 2. read current version
-			`call: bin.ReadDir(".")`,        // 3. read installed versions
-			`call: bin.Remove("go")`,        // 4. remove symlink (switch to main)
-			`call: bin.Remove("go1.18")`,    // 4. remove 1.18 binary
-			`call: sdk.RemoveAll("go1.18")`, // 5. remove 1.18 SDK
+			`exec: go version`,             // 1. read main version
+			`call: bin.Lstat("go")`,        // 2. check go symlink
+			`call: bin.Readlink("go")`,     // 3. read current version
+			`call: bin.ReadDir(".")`,       // 4. read installed versions
+			`call: state.ReadFile("pins")`, // 5. read pinned versions
 		})
 	})
 
 	t.Run("remove non-existing version", func(t *testing.T) {
 		var steps []string
 
-		app := app.App{
-			GoBin: spyFS{
+		a := app.App{
+			GoBin: &spyFS{
 				dir:   "bin",
 				link:  "/path/to/go1.18",
 				files: []string{"go1.18"},
 				calls: &steps,
 			},
-			SDK: spyFS{
+			SDK: &spyFS{
 				dir:   "sdk",
 				files: []string{"go1.18/.unpacked-success"},
 				calls: &steps,
 			},
 			Output: io.Discard,
 		}
-		recordCmds(&app, &steps, "go version go1.20")
+		recordCmds(&a, &steps, "go version go1.20")
 
-		err := app.Remove(context.Background(), "1.19")
-		assert.Equal[F](t, err.Error(), "1.19 is not installed")
+		err := a.Remove(context.Background(), "1.19", app.RemoveOptions{})
+		assert.Equal[F](t, err.Error(), "1.19: version is not installed")
+		assert.Equal[E](t, app.ErrorCode(err), "not_installed")
 		assert.Equal[E](t, steps, []string{
 			`exec: go version`,         // 1. read main version
-			`call: bin.Readlink("go")`, // 2. read current version
-			`call: bin.ReadDir(".")`,   // 3. read installed versions
+			`call: bin.Lstat("go")`,    // 2. check go symlink
+			`call: bin.Readlink("go")`, // 3. read current version
+			`call: bin.ReadDir(".")`,   // 4. read installed versions
 		})
 	})
 }
 
+func TestApp_RemoveUnused(t *testing.T) {
+	var buf bytes.Buffer
+
+	bin := &spyFS{dir: "bin", link: "/path/to/go1.21.6", files: []string{"go1.21.6", "go1.21.5", "go1.19", "go1.18", "go1.17"}, calls: new([]string)}
+	a := app.App{
+		GoBin: bin,
+		SDK: &spyFS{
+			dir:   "sdk",
+			files: []string{"go1.21.6/.unpacked-success", "go1.21.5/.unpacked-success", "go1.19/.unpacked-success", "go1.18/.unpacked-success"},
+			modTimes: map[string]time.Time{
+				"go1.21.6": now().AddDate(0, -6, 0), // current.
+				"go1.21.5": now().AddDate(0, 0, -10),
+				"go1.19":   now().AddDate(-1, 0, 0), // pinned.
+				"go1.18":   now().AddDate(-1, 0, 0),
+			},
+			calls: new([]string),
+		},
+		State:  &spyFS{dir: "state", contents: map[string]string{"pins": "1.19\n"}, calls: new([]string)},
+		Output: &buf,
+		Now:    now,
+	}
+	recordCmds(&a, new([]string), "go version go1.20")
+
+	// 1. dry run
+	err := a.RemoveUnused(context.Background(), app.RemoveOptions{OlderThan: 90 * 24 * time.Hour, DryRun: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "Would remove 1.18 (SDK last modified 2023-01-01)\n")
+
+	// 2. actual removal
+	buf.Reset()
+	err = a.RemoveUnused(context.Background(), app.RemoveOptions{OlderThan: 90 * 24 * time.Hour})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "Removed 1.18\n")
+	assert.Equal[E](t, slices.Contains(*bin.calls, `call: bin.Remove("go1.18")`), true)
+
+	// 3. nothing to remove
+	buf.Reset()
+	err = a.RemoveUnused(context.Background(), app.RemoveOptions{OlderThan: 2 * 365 * 24 * time.Hour})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "No unused versions have been found\n")
+}
+
+func TestApp_RemoveOldPatches(t *testing.T) {
+	var buf bytes.Buffer
+
+	bin := &spyFS{
+		dir:   "bin",
+		link:  "/path/to/go1.21.3",
+		files: []string{"go1.21.6", "go1.21.5", "go1.21.4", "go1.21.3", "go1.21.2", "go1.20.2", "go1.20.1"},
+		calls: new([]string),
+	}
+	a := app.App{
+		GoBin:  bin,
+		SDK:    &spyFS{dir: "sdk", files: []string{"go1.21.2/.unpacked-success"}, calls: new([]string)},
+		State:  &spyFS{dir: "state", contents: map[string]string{"pins": "1.21.4\n"}, calls: new([]string)},
+		Output: &buf,
+	}
+	recordCmds(&a, new([]string), "go version go1.20")
+
+	// 1. dry run across all minor versions (1.21.4 is pinned, 1.21.3 is current, 1.20 is main)
+	err := a.RemoveOldPatches(context.Background(), "", app.RemoveOptions{Keep: 1, DryRun: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "Would remove 1.21.5\nWould remove 1.21.2\nWould remove 1.20.1\n")
+
+	// 2. actual removal for a single minor version
+	buf.Reset()
+	err = a.RemoveOldPatches(context.Background(), "1.21", app.RemoveOptions{Keep: 2})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "Removed 1.21.2\n")
+	assert.Equal[E](t, slices.Contains(*bin.calls, `call: bin.Remove("go1.20.1")`), false)
+
+	// 3. nothing to remove
+	buf.Reset()
+	err = a.RemoveOldPatches(context.Background(), "1.20", app.RemoveOptions{Keep: 3})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "No older patches have been found\n")
+
+	// 4. not a minor version
+	err = a.RemoveOldPatches(context.Background(), "1.21.6", app.RemoveOptions{Keep: 1})
+	assert.Equal[E](t, err.Error(), `malformed minor version "1.21.6"`)
+}
+
+func TestApp_Reinstall(t *testing.T) {
+	var steps []string
+	var buf bytes.Buffer
+
+	a := app.App{
+		GoBin: &spyFS{
+			dir:   "bin",
+			link:  "/path/to/go1.18",
+			files: []string{"go1.18"},
+			calls: &steps,
+		},
+		SDK: &spyFS{
+			dir:   "sdk",
+			files: []string{"go1.18/.unpacked-success"},
+			calls: &steps,
+		},
+		Output: &buf,
+	}
+	recordCmds(&a, &steps, "go version go1.20")
+
+	err := a.Reinstall(context.Background(), "1.18")
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, "\n"+buf.String(), `
+Temporarily switched to 1.20 (main)
+Removed 1.18 SDK. Starting download ...
+Switched back to 1.18
+Reinstalled 1.18
+`)
+	assert.Equal[E](t, steps, []string{
+		`exec: go version`,                  // 1. read main version
+		`call: bin.Lstat("go")`,             // 2. check go symlink
+		`call: bin.Readlink("go")`,          // 3. read current version
+		`call: bin.ReadDir(".")`,            // 4. read installed versions
+		`call: bin.Remove("go")`,            // 5. remove symlink (switch to main)
+		`call: bin.Stat("go1.18")`,          // 6. check 1.18 binary
+		`call: sdk.RemoveAll("go1.18")`,     // 7. remove 1.18 SDK
+		`exec: go1.18 download`,             // 8. download 1.18 SDK
+		`call: bin.Symlink("go1.18", "go")`, // 9. create symlink (switch back)
+		`call: bin.Readlink("go")`,          // 10. verify symlink
+	})
+	err = a.Reinstall(context.Background(), "1.19")
+	assert.IsErr[F](t, err, app.ErrNotInstalled)
+	assert.Equal[E](t, err.Error(), "1.19: version is not installed")
+
+	runCmd := a.RunCmd
+	a.RunCmd = func(ctx context.Context, name string, args ...string) error {
+		if name == "go1.18" {
+			return errors.New("exit status 1")
+		}
+		return runCmd(ctx, name, args...)
+	}
+	err = a.Reinstall(context.Background(), "1.18")
+	assert.Equal[E](t, err.Error(), "reinstall of 1.18 failed; switched to 1.20 (main): downloading go1.18 SDK: exit status 1")
+}
+
+func TestLookPath(t *testing.T) {
+	ext := ""
+	if runtime.GOOS == "windows" {
+		ext = ".exe"
+	}
+
+	empty, main := t.TempDir(), t.TempDir()
+	err := os.WriteFile(filepath.Join(main, "go"+ext), []byte("#!/bin/sh\n"), 0o755)
+	assert.NoErr[F](t, err)
+
+	path := strings.Join([]string{"relative", empty, main}, string(os.PathListSeparator))
+	name, err := app.LookPath("go", path)
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, name, filepath.Join(main, "go"+ext))
+
+	_, err = app.LookPath("go", empty)
+	assert.IsErr[E](t, err, exec.ErrNotFound)
+}
+
+func TestApp_Bootstrap(t *testing.T) {
+	var steps []string
+	var buf bytes.Buffer
+
+	env := mapEnv{"PATH": "/usr/local/go/bin", "GOBIN": "/home/gopher/go/bin"}
+	a := app.App{
+		Output:   &buf,
+		Env:      env,
+		LookPath: func(file, path string) (string, error) { return file, nil },
+	}
+	recordCmds(&a, &steps, "go version go1.20")
+
+	err := a.Bootstrap(context.Background())
+	assert.Equal[E](t, err.Error(), "1 of 4 checks failed")
+	assert.Equal[E](t, "\n"+buf.String(), `
+ok   main Go toolchain: 1.20
+FAIL $GOBIN in $PATH: add /home/gopher/go/bin to $PATH to use the installed versions
+ok   golang.org/dl module: go version go1.20
+ok   go install (dry run): golang.org/dl/gotip@latest
+`)
+	assert.Equal[E](t, steps, []string{
+		`exec: go version`,                               // 1. check main version
+		`exec: go list -m golang.org/dl@latest`,          // 2. check golang.org/dl access
+		`exec: go install -n golang.org/dl/gotip@latest`, // 3. dry run go install
+	})
+
+	buf.Reset()
+	env["PATH"] = "/home/gopher/go/bin" + string(os.PathListSeparator) + "/usr/local/go/bin"
+	err = a.Bootstrap(context.Background())
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, env["PATH"], "/home/gopher/go/bin"+string(os.PathListSeparator)+"/usr/local/go/bin")
+
+	buf.Reset()
+	steps = nil
+	a.ConfirmNetwork = func(string) bool { return false }
+	err = a.Bootstrap(context.Background())
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, "\n"+buf.String(), `
+ok   main Go toolchain: 1.20
+ok   $GOBIN in $PATH: /home/gopher/go/bin
+skip golang.org/dl module: network access has been declined
+skip go install (dry run): network access has been declined
+`)
+	assert.Equal[E](t, steps, []string{`exec: go version`})
+}
+
+func TestApp_Status(t *testing.T) {
+	var buf bytes.Buffer
+
+	a := app.App{
+		GoBin:  &spyFS{dir: "bin", link: "/path/to/go1.21.6", files: []string{"go1.21.6"}, calls: new([]string)},
+		SDK:    &spyFS{dir: "sdk", files: []string{"go1.21.6/.unpacked-success"}, calls: new([]string)},
+		Output: &buf,
+	}
+	recordCmds(&a, new([]string), "go version go1.20")
+
+	// 1. no .go-version
+	dir := filepath.Join(t.TempDir(), "project", "cmd")
+	assert.NoErr[F](t, os.MkdirAll(dir, 0o755))
+	err := a.Status(context.Background(), dir, app.StatusOptions{})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "1.21.6\n")
+
+	buf.Reset()
+	err = a.Status(context.Background(), dir, app.StatusOptions{JSON: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), `{"schema":1,"active":"1.21.6","match":true,"sdk":true}`+"\n")
+
+	// 2. .go-version in a parent directory
+	buf.Reset()
+	assert.NoErr[F](t, os.WriteFile(filepath.Join(dir, "..", ".go-version"), []byte("1.20\n"), 0o644))
+	err = a.Status(context.Background(), dir, app.StatusOptions{})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "1.21.6 (.go-version: 1.20)\n")
+
+	// 3. JSON
+	buf.Reset()
+	err = a.Status(context.Background(), dir, app.StatusOptions{JSON: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), `{"schema":1,"active":"1.21.6","pinned":"1.20","match":false,"sdk":true}`+"\n")
+
+	// 4. GOROOT shadows the SDK
+	var errs bytes.Buffer
+	buf.Reset()
+	a.Errors = &errs
+	a.Env = mapEnv{"GOROOT": "/usr/local/go"}
+	err = a.Status(context.Background(), dir, app.StatusOptions{})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "1.21.6 (.go-version: 1.20, shadowed by GOROOT)\n")
+	assert.Equal[E](t, errs.String(), "Warning: GOROOT=/usr/local/go shadows the 1.21.6 SDK, so switching versions has no effect; run `unset GOROOT` to fix it\n")
+
+	// 5. GOROOT points to the SDK
+	errs.Reset()
+	buf.Reset()
+	a.Env = mapEnv{"GOROOT": "/sdk/go1.21.6/"}
+	err = a.Status(context.Background(), dir, app.StatusOptions{})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "1.21.6 (.go-version: 1.20)\n")
+	assert.Equal[E](t, errs.String(), "")
+}
+
+func TestApp_PrintEnv(t *testing.T) {
+	var buf bytes.Buffer
+
+	a := app.App{
+		GoBin:  &spyFS{dir: "bin", link: "/path/to/go1.21.6", files: []string{"go1.21.6", "go1.19"}, calls: new([]string)},
+		SDK:    &spyFS{dir: "sdk", files: []string{"go1.21.6/.unpacked-success"}, calls: new([]string)},
+		Output: &buf,
+		Env:    mapEnv{"PATH": "/usr/bin"},
+	}
+	recordCmds(&a, new([]string), "go version go1.20")
+
+	err := a.PrintEnv(context.Background(), "")
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), ""+
+		"GOROOT=/sdk/go1.21.6\n"+
+		"PATH="+filepath.Join("/sdk/go1.21.6", "bin")+string(os.PathListSeparator)+"/usr/bin\n")
+
+	err = a.PrintEnv(context.Background(), "1.19")
+	assert.IsErr[F](t, err, app.ErrNotInstalled)
+}
+
+func TestApp_Files(t *testing.T) {
+	var steps []string
+	var buf bytes.Buffer
+
+	a := app.App{
+		Output: &buf,
+		Requester: httpSpy{
+			requests: &steps,
+			response: `[{"version":"go1.21.6","files":[
+				{"filename":"go1.21.6.src.tar.gz","os":"","arch":"","kind":"source","size":26873062,"sha256":"124926"},
+				{"filename":"go1.21.6.darwin-arm64.pkg","os":"darwin","arch":"arm64","kind":"installer","size":65574112,"sha256":"9e4aef"}
+			]},{"version":"go1.21.5","files":[]}]`,
+		},
+	}
+
+	err := a.Files(context.Background(), "1.21.6", app.FilesOptions{})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, "\n"+buf.String(), `
+go1.21.6.src.tar.gz       -            source     25.6 MiB 124926
+go1.21.6.darwin-arm64.pkg darwin/arm64 installer  62.5 MiB 9e4aef
+`)
+	assert.Equal[E](t, steps, []string{
+		`http: https://go.dev/dl/?mode=json&include=all`, // 1. get remote versions
+	})
+
+	buf.Reset()
+	err = a.Files(context.Background(), "1.21.5", app.FilesOptions{JSON: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), `{
+  "schema": 1,
+  "version": "1.21.5",
+  "files": []
+}
+`)
+
+	err = a.Files(context.Background(), "1.20", app.FilesOptions{})
+	assert.Equal[E](t, err.Error(), "1.20 is not available on go.dev")
+}
+
+func TestApp_Which(t *testing.T) {
+	var buf bytes.Buffer
+
+	a := app.App{
+		GoBin:  &spyFS{dir: "bin", link: "go1.21.6", files: []string{"go1.21.6", "go1.19", "gotip"}, calls: new([]string)},
+		SDK:    &spyFS{dir: "sdk", calls: new([]string)},
+		Output: &buf,
+	}
+	recordCmds(&a, new([]string), "go version go1.20")
+
+	// 1. the current version
+	err := a.Which(context.Background(), app.WhichOptions{})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "/bin/go1.21.6\n")
+
+	// 2. all versions
+	buf.Reset()
+	err = a.Which(context.Background(), app.WhichOptions{All: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, "\n"+buf.String(), `
+  tip    /bin/gotip
+* 1.21.6 /bin/go1.21.6
+  1.19   /bin/go1.19
+
+/bin/go -> /bin/go1.21.6
+`)
+
+	// 3. JSON
+	buf.Reset()
+	err = a.Which(context.Background(), app.WhichOptions{All: true, JSON: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, strings.Contains(buf.String(), `"link": "/bin/go1.21.6"`), true)
+	assert.Equal[E](t, strings.Contains(buf.String(), `"path": "/bin/gotip"`), true)
+
+	// 4. copy mode
+	buf.Reset()
+	a.GoBin = &spyFS{
+		dir:      "bin",
+		files:    []string{"go", "go1.19"},
+		contents: map[string]string{"go": "go1.19 wrapper", "go1.19": "go1.19 wrapper"},
+		calls:    new([]string),
+	}
+	err = a.Which(context.Background(), app.WhichOptions{All: true})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "* 1.19 /bin/go1.19\n")
+}
+
+func TestApp_ShellEnv(t *testing.T) {
+	var buf bytes.Buffer
+	a := app.App{
+		Output: &buf,
+		Env:    mapEnv{"SHELL": "/bin/zsh"},
+	}
+
+	err := a.ShellEnv("")
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, strings.Contains(buf.String(), "add-zsh-hook chpwd _goversion_hook"), true)
+	assert.Equal[E](t, strings.Contains(buf.String(), `dir="${dir%/*}"`), true) // .go-version is looked up in the parents too.
+
+	err = a.ShellEnv("tcsh")
+	assert.Equal[E](t, err.Error(), `unsupported shell "tcsh" (supported: bash, zsh, fish)`)
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := map[error]string{
+		fmt.Errorf("1.99: %w", app.ErrNotInstalled): "not_installed",
+		app.ErrMissingSDK: "missing_sdk",
+		fmt.Errorf("interrupted: %w", context.Canceled):                  "interrupted",
+		fmt.Errorf("timed out after 1m0s: %w", context.DeadlineExceeded): "timeout",
+		errors.New("exit status 1"):                                      "error",
+	}
+	for err, code := range tests {
+		assert.Equal[E](t, app.ErrorCode(err), code, err)
+	}
+}
+
+func TestApp_Pin(t *testing.T) {
+	var steps []string
+	var buf bytes.Buffer
+
+	state := &spyFS{dir: "state", calls: &steps}
+	a := app.App{
+		State:  state,
+		Output: &buf,
+	}
+
+	assert.NoErr[F](t, a.Pin("1.18"))
+	assert.NoErr[F](t, a.Pin("1.21.6"))
+	assert.NoErr[F](t, a.Pin("1.18"))
+	assert.Equal[E](t, state.contents["pins"], "1.21.6\n1.18\n")
+
+	assert.NoErr[F](t, a.Unpin("1.18"))
+	assert.Equal[E](t, state.contents["pins"], "1.21.6\n")
+
+	err := a.Unpin("1.18")
+	assert.Equal[E](t, err.Error(), "1.18 is not pinned")
+
+	assert.NoErr[F](t, a.ListPins())
+	assert.Equal[E](t, buf.String(), "Pinned 1.18\nPinned 1.21.6\n1.18 is already pinned\nUnpinned 1.18\n1.21.6\n")
+
+	a.State = nil
+	err = a.Pin("1.18")
+	assert.Equal[E](t, err.Error(), "no state directory is configured")
+}
+
+func now() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+
 func recordCmds(app *app.App, cmds *[]string, cmdOut string) {
 	app.RunCmd = func(ctx context.Context, name string, args ...string) error {
 		*cmds = append(*cmds, fmt.Sprintf("exec: %s %s", name, strings.Join(args, " ")))
@@ -254,39 +2675,95 @@ func recordCmds(app *app.App, cmds *[]string, cmdOut string) {
 	}
 }
 
+// onInstall calls fn whenever `go install` is executed.
+func onInstall(app *app.App, fn func()) {
+	runCmd := app.RunCmd
+	app.RunCmd = func(ctx context.Context, name string, args ...string) error {
+		if name == "go" && len(args) > 0 && args[0] == "install" {
+			fn()
+		}
+		return runCmd(ctx, name, args...)
+	}
+}
+
+type mapEnv map[string]string
+
+func (e mapEnv) LookupEnv(key string) (string, bool) {
+	value, ok := e[key]
+	return value, ok
+}
+
+func (e mapEnv) Setenv(key, value string) error {
+	e[key] = value
+	return nil
+}
+
+func (e mapEnv) Unsetenv(key string) error {
+	delete(e, key)
+	return nil
+}
+
 type spyFS struct {
-	dir   string
-	link  string
-	files []string
-	calls *[]string
+	dir      string
+	link     string
+	badLink  string // if set, Symlink links to it instead (e.g. on a misbehaving filesystem).
+	files    []string
+	contents map[string]string
+	modTimes map[string]time.Time // the modification times reported by Stat.
+	readOnly bool                 // if set, WriteFile fails (e.g. on a read-only home directory).
+	calls    *[]string
 }
 
-func (s spyFS) Open(name string) (fs.File, error) { panic("unimplemented") }
+func (s *spyFS) Open(name string) (fs.File, error) { panic("unimplemented") }
 
-func (s spyFS) Stat(name string) (fs.FileInfo, error) {
+func (s *spyFS) Stat(name string) (fs.FileInfo, error) {
 	*s.calls = append(*s.calls, fmt.Sprintf("call: %s.Stat(%q)", s.dir, name))
-	if slices.Contains(s.files, name) {
-		return nil, nil
+	var info fs.FileInfo
+	switch {
+	case slices.Contains(s.files, name):
+		info = fileInfo(name)
+	case slices.ContainsFunc(s.files, func(f string) bool { return strings.HasPrefix(f, name+"/") }):
+		info = dirInfo(name)
+	default:
+		return nil, fs.ErrNotExist
 	}
-	return nil, fs.ErrNotExist
+	if t, ok := s.modTimes[name]; ok {
+		return modTimeInfo{info, t}, nil
+	}
+	return info, nil
 }
 
-func (s spyFS) Remove(name string) error {
+func (s *spyFS) Remove(name string) error {
 	*s.calls = append(*s.calls, fmt.Sprintf("call: %s.Remove(%q)", s.dir, name))
+	delete(s.contents, name)
 	return nil
 }
 
-func (s spyFS) RemoveAll(name string) error {
+func (s *spyFS) RemoveAll(name string) error {
 	*s.calls = append(*s.calls, fmt.Sprintf("call: %s.RemoveAll(%q)", s.dir, name))
 	return nil
 }
 
-func (s spyFS) Symlink(oldname, newname string) error {
+func (s *spyFS) Symlink(oldname, newname string) error {
 	*s.calls = append(*s.calls, fmt.Sprintf("call: %s.Symlink(%q, %q)", s.dir, oldname, newname))
+	s.link = cmp.Or(s.badLink, path.Join("/", s.dir, oldname))
 	return nil
 }
 
-func (s spyFS) Readlink(name string) (string, error) {
+func (s *spyFS) Lstat(name string) (fs.FileInfo, error) {
+	*s.calls = append(*s.calls, fmt.Sprintf("call: %s.Lstat(%q)", s.dir, name))
+	switch {
+	case s.link != "":
+		return symlinkInfo(name), nil
+	case slices.Contains(s.files, name) || s.contents[name] != "":
+		return fileInfo(name), nil
+	}
+	return nil, fs.ErrNotExist
+}
+
+func (s *spyFS) Path(name string) string { return path.Join("/", s.dir, name) }
+
+func (s *spyFS) Readlink(name string) (string, error) {
 	*s.calls = append(*s.calls, fmt.Sprintf("call: %s.Readlink(%q)", s.dir, name))
 	if s.link == "" {
 		return "", fs.ErrNotExist
@@ -294,29 +2771,117 @@ func (s spyFS) Readlink(name string) (string, error) {
 	return s.link, nil
 }
 
-func (s spyFS) ReadDir(name string) ([]fs.DirEntry, error) {
+func (s *spyFS) ReadFile(name string) ([]byte, error) {
+	*s.calls = append(*s.calls, fmt.Sprintf("call: %s.ReadFile(%q)", s.dir, name))
+	data, ok := s.contents[name]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return []byte(data), nil
+}
+
+func (s *spyFS) WriteFile(name string, data []byte, _ fs.FileMode) error {
+	*s.calls = append(*s.calls, fmt.Sprintf("call: %s.WriteFile(%q, %q)", s.dir, name, data))
+	if s.readOnly {
+		return &fs.PathError{Op: "open", Path: path.Join(s.dir, name), Err: fs.ErrPermission}
+	}
+	if s.contents == nil {
+		s.contents = make(map[string]string)
+	}
+	s.contents[name] = string(data)
+	return nil
+}
+
+func (s *spyFS) ReadDir(name string) ([]fs.DirEntry, error) {
 	*s.calls = append(*s.calls, fmt.Sprintf("call: %s.ReadDir(%q)", s.dir, name))
-	entries := make([]fs.DirEntry, len(s.files))
-	for i, f := range s.files {
-		entries[i] = dirFile(f)
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	var entries []fs.DirEntry
+	for _, f := range s.files {
+		f, ok := strings.CutPrefix(f, prefix)
+		if !ok {
+			continue
+		}
+		// only the direct entries are returned, e.g. go1.18 for go1.18/.unpacked-success.
+		name, _, isDir := strings.Cut(f, "/")
+		if !slices.ContainsFunc(entries, func(e fs.DirEntry) bool { return e.Name() == name }) {
+			entries = append(entries, dirEntry{name, isDir})
+		}
 	}
 	return entries, nil
 }
 
-type dirFile string
+type dirEntry struct {
+	name  string
+	isDir bool
+}
+
+func (e dirEntry) Name() string { return e.name }
+func (e dirEntry) IsDir() bool  { return e.isDir }
+func (e dirEntry) Type() fs.FileMode {
+	if e.isDir {
+		return fs.ModeDir
+	}
+	return 0
+}
+func (e dirEntry) Info() (fs.FileInfo, error) {
+	if e.isDir {
+		return dirInfo(e.name), nil
+	}
+	return fileInfo(e.name), nil
+}
+
+type fileInfo string
+
+func (f fileInfo) Name() string       { return string(f) }
+func (f fileInfo) Size() int64        { return 1 }
+func (f fileInfo) Mode() fs.FileMode  { return 0o755 }
+func (f fileInfo) ModTime() time.Time { return time.Time{} }
+func (f fileInfo) IsDir() bool        { return false }
+func (f fileInfo) Sys() any           { return nil }
 
-func (f dirFile) Name() string               { return string(f) }
-func (f dirFile) IsDir() bool                { return false }
-func (f dirFile) Type() fs.FileMode          { panic("unimplemented") }
-func (f dirFile) Info() (fs.FileInfo, error) { panic("unimplemented") }
+type dirInfo string
+
+func (f dirInfo) Name() string       { return string(f) }
+func (f dirInfo) Size() int64        { return 0 }
+func (f dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o755 }
+func (f dirInfo) ModTime() time.Time { return time.Time{} }
+func (f dirInfo) IsDir() bool        { return true }
+func (f dirInfo) Sys() any           { return nil }
+
+type symlinkInfo string
+
+func (f symlinkInfo) Name() string       { return string(f) }
+func (f symlinkInfo) Size() int64        { return 0 }
+func (f symlinkInfo) Mode() fs.FileMode  { return fs.ModeSymlink | 0o777 }
+func (f symlinkInfo) ModTime() time.Time { return time.Time{} }
+func (f symlinkInfo) IsDir() bool        { return false }
+func (f symlinkInfo) Sys() any           { return nil }
+
+type modTimeInfo struct {
+	fs.FileInfo
+	modTime time.Time
+}
+
+func (f modTimeInfo) ModTime() time.Time { return f.modTime }
 
 type httpSpy struct {
-	requests *[]string
-	response string
+	requests  *[]string
+	response  string
+	err       error
+	userAgent *string // optional, records the User-Agent header.
 }
 
 func (s httpSpy) Do(req *http.Request) (*http.Response, error) {
 	*s.requests = append(*s.requests, "http: "+req.URL.String())
+	if s.userAgent != nil {
+		*s.userAgent = req.Header.Get("User-Agent")
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
 	return &http.Response{
 		Body: io.NopCloser(strings.NewReader(s.response)),
 	}, nil
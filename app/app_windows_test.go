@@ -0,0 +1,41 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"go-simpler.org/assert"
+	. "go-simpler.org/assert/EF"
+	"go-simpler.org/goversion/app"
+)
+
+func TestApp_List_windows(t *testing.T) {
+	var steps []string
+	var buf bytes.Buffer
+
+	a := app.App{
+		GoBin: &spyFS{
+			dir:   "bin",
+			files: []string{"gotip.exe"},
+			calls: &steps,
+		},
+		SDK: &spyFS{
+			dir:   "sdk",
+			files: []string{"gotip/bin/go.exe"},
+			calls: &steps,
+		},
+		Output: &buf,
+	}
+	recordCmds(&a, &steps, "go version go1.20 windows/amd64")
+
+	err := a.List(context.Background(), app.ListOptions{})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, buf.String(), "  tip \n* 1.20 (main)\n")
+	assert.Equal[E](t, steps, []string{
+		`exec: go version`,                   // 1. read main version
+		`call: bin.Lstat("go.exe")`,          // 2. check go symlink
+		`call: bin.ReadDir(".")`,             // 3. read installed versions
+		`call: sdk.Stat("gotip/bin/go.exe")`, // 4. check tip SDK
+	})
+}
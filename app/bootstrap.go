@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Bootstrap checks that a fresh machine is able to install Go versions:
+// the main go binary works, $GOBIN is in $PATH, and golang.org/dl is reachable.
+// Each check is reported; nothing is actually installed.
+// The checks that need the network are skipped if the access is declined (see [App.ConfirmNetwork]).
+func (a *App) Bootstrap(ctx context.Context) error {
+	env := a.env()
+	path, _ := env.LookupEnv("PATH")
+	gobin, _ := env.LookupEnv("GOBIN")
+
+	checks := []struct {
+		name string
+		run  func() (string, error)
+	}{
+		{"main Go toolchain", func() (string, error) {
+			output, err := a.runMainGo(ctx, "version")
+			if err != nil {
+				return "", a.ctxError(ctx, err)
+			}
+			version, ok := parseGoVersion(output)
+			if !ok {
+				return "", fmt.Errorf("unexpected format %q", output)
+			}
+			return version, nil
+		}},
+		{"$GOBIN in $PATH", func() (string, error) {
+			if cutFromPath(path, gobin) == path {
+				return "", fmt.Errorf("add %s to $PATH to use the installed versions", gobin)
+			}
+			return gobin, nil
+		}},
+		{"golang.org/dl module", func() (string, error) {
+			if err := a.confirmNetwork("run `go list -m golang.org/dl@latest` (queries GOPROXY)"); err != nil {
+				return "", err
+			}
+			output, err := a.runMainGo(ctx, "list", "-m", "golang.org/dl@latest")
+			if err != nil {
+				return "", a.ctxError(ctx, err)
+			}
+			return strings.TrimSpace(output), nil
+		}},
+		{"go install (dry run)", func() (string, error) {
+			if err := a.confirmNetwork("run `go install -n golang.org/dl/gotip@latest` (downloads the module via GOPROXY)"); err != nil {
+				return "", err
+			}
+			// -n prints the commands without running them, so nothing is installed.
+			if _, err := a.runMainGo(ctx, "install", "-n", "golang.org/dl/gotip@latest"); err != nil {
+				return "", a.ctxError(ctx, err)
+			}
+			return "golang.org/dl/gotip@latest", nil
+		}},
+	}
+
+	var failed int
+	for _, check := range checks {
+		details, err := check.run()
+		if errors.Is(err, ErrNetworkDeclined) {
+			fmt.Fprintf(a.Output, "skip %s: %v\n", check.name, err)
+			continue
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(a.Output, "FAIL %s: %v\n", check.name, err)
+			continue
+		}
+		fmt.Fprintf(a.Output, "ok   %s: %s\n", check.name, details)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
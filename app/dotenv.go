@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// PrintEnv prints GOROOT and PATH for the version (the current one if empty) in the dotenv format,
+// e.g. to be sourced in a later stage of a Docker build.
+func (a *App) PrintEnv(ctx context.Context, version string) error {
+	local, err := a.localVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch version {
+	case "":
+		version = local.current
+	case "main":
+		version = local.main
+	}
+
+	if !isValid(version) {
+		return fmt.Errorf("malformed version %q", version)
+	}
+
+	var goroot string
+	switch {
+	case version == local.main:
+		output, err := a.runMainGo(ctx, "env", "GOROOT")
+		if err != nil {
+			return err
+		}
+		goroot = strings.TrimSpace(output)
+	case !slices.Contains(local.list, version) || !a.downloaded(version):
+		return fmt.Errorf("%s: %w", version, ErrNotInstalled)
+	default:
+		goroot = a.sdkPath(local, version)
+	}
+
+	path, _ := a.env().LookupEnv("PATH")
+	path = filepath.Join(goroot, "bin") + string(os.PathListSeparator) + path
+
+	fmt.Fprintf(a.Output, "GOROOT=%s\n", goroot)
+	fmt.Fprintf(a.Output, "PATH=%s\n", path)
+	return nil
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// Env is the environment of the process.
+// It's abstracted so that tests don't have to modify the real environment.
+type Env interface {
+	LookupEnv(key string) (string, bool)
+	Setenv(key, value string) error
+	Unsetenv(key string) error
+}
+
+// OSEnv is the real environment of the process.
+type OSEnv struct{}
+
+func (OSEnv) LookupEnv(key string) (string, bool) { return os.LookupEnv(key) }
+func (OSEnv) Setenv(key, value string) error      { return os.Setenv(key, value) }
+func (OSEnv) Unsetenv(key string) error           { return os.Unsetenv(key) }
+
+func (a *App) env() Env {
+	if a.Env == nil {
+		return OSEnv{}
+	}
+	return a.Env
+}
+
+func (a *App) lookPath(file, path string) (string, error) {
+	if a.LookPath == nil {
+		return LookPath(file, path)
+	}
+	return a.LookPath(file, path)
+}
+
+func (a *App) now() time.Time {
+	if a.Now == nil {
+		return time.Now()
+	}
+	return a.Now()
+}
+
+func (a *App) sleep(ctx context.Context, d time.Duration) error {
+	if a.Sleep != nil {
+		return a.Sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// LookPath searches for the executable in the directories of the $PATH value,
+// similar to [exec.LookPath], but without reading the environment of the process.
+func LookPath(file, path string) (string, error) {
+	for _, dir := range filepath.SplitList(path) {
+		if !filepath.IsAbs(dir) {
+			continue // see https://go.dev/blog/path-security.
+		}
+		name := filepath.Join(dir, file+exe())
+		info, err := os.Stat(name)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if runtime.GOOS == "windows" || info.Mode()&0o111 != 0 {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%s: %w", file, exec.ErrNotFound)
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FilesOptions configures the output of [App.Files].
+type FilesOptions struct {
+	JSON bool // print the output in the JSON format.
+}
+
+// Files prints the downloads of the version listed on go.dev (file name, platform, kind, size and SHA256),
+// e.g. for manual downloads and auditing.
+func (a *App) Files(ctx context.Context, version string, opts FilesOptions) error {
+	version = strings.TrimSpace(version) // see [App.Use].
+	if !isValid(version) {
+		return fmt.Errorf("malformed version %q", version)
+	}
+	if version == "tip" {
+		return errors.New("tip has no downloads on go.dev")
+	}
+
+	var list []release
+	err := a.fetch(ctx, releasesURL, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&list)
+	})
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return ErrNoVersions
+	}
+
+	var files []releaseFile
+	found := false
+	for _, r := range list {
+		if r.Version == "go"+version {
+			files, found = r.Files, true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("%s is not available on go.dev", version)
+	}
+
+	if opts.JSON {
+		return a.printJSON(filesJSON{Schema: JSONSchema, Version: version, Files: append([]releaseFile{}, files...)})
+	}
+
+	// source archives have no platform.
+	platforms := make([]string, len(files))
+	var nameLen, platformLen, kindLen int
+	for i, f := range files {
+		platforms[i] = "-"
+		if f.OS != "" || f.Arch != "" {
+			platforms[i] = f.OS + "/" + f.Arch
+		}
+		nameLen = max(nameLen, len(f.Filename))
+		platformLen = max(platformLen, len(platforms[i]))
+		kindLen = max(kindLen, len(f.Kind))
+	}
+
+	for i, f := range files {
+		fmt.Fprintf(a.Output, "%-*s %-*s %-*s %9s %s\n",
+			nameLen, f.Filename, platformLen, platforms[i], kindLen, f.Kind, formatSize(f.Size), f.SHA256)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// JSONSchema is the version of the JSON output format, reported in the "schema" field of every JSON document.
+// It must be incremented on every breaking change (e.g. a removed or renamed field).
+const JSONSchema = 1
+
+type listJSON struct {
+	Schema   int           `json:"schema"`
+	Versions []versionJSON `json:"versions"`
+}
+
+type versionJSON struct {
+	Version string `json:"version"`
+	Status  string `json:"status"`  // the same as in the porcelain output.
+	Channel string `json:"channel"` // stable, rc, beta or tip.
+	Current bool   `json:"current"`
+	SDKPath string `json:"sdk_path,omitempty"`
+	Update  string `json:"update,omitempty"`         // only with -outdated.
+	Behind  int    `json:"patches_behind,omitempty"` // only with -outdated.
+	Size    int64  `json:"size,omitempty"`           // the SDK size in bytes; only with -sizes.
+	Compare string `json:"compare,omitempty"`        // newer, same or older; only with -compare.
+}
+
+func (a *App) printJSON(v any) error {
+	enc := json.NewEncoder(a.Output)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+type infoJSON struct {
+	Schema int `json:"schema"`
+	versionJSON
+	Installed bool   `json:"installed"`
+	SDKSize   int64  `json:"sdk_size,omitempty"` // in bytes.
+	Ref       string `json:"ref,omitempty"`      // the ref tip was built at, if known.
+}
+
+type statusJSON struct {
+	Schema int    `json:"schema"`
+	Active string `json:"active"`
+	Pinned string `json:"pinned,omitempty"` // the version from .go-version, if any.
+	Match  bool   `json:"match"`            // true if there is no .go-version.
+	SDK    bool   `json:"sdk"`
+	GOROOT string `json:"shadowing_goroot,omitempty"` // the GOROOT environment variable, if it shadows the SDK.
+}
+
+type whichJSON struct {
+	Schema   int          `json:"schema"`
+	Binaries []binaryJSON `json:"binaries"`
+	Link     string       `json:"link,omitempty"` // the target of $GOBIN/go, if it's managed by goversion.
+}
+
+type binaryJSON struct {
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	Current bool   `json:"current"`
+}
+
+type filesJSON struct {
+	Schema  int           `json:"schema"`
+	Version string        `json:"version"`
+	Files   []releaseFile `json:"files"`
+}
+
+// ErrorCode returns the machine-readable code of the error for the JSON output, e.g. "not_installed".
+// Errors without a dedicated code are reported as "error".
+func ErrorCode(err error) string {
+	for _, e := range []struct {
+		err  error
+		code string
+	}{
+		{ErrNotInstalled, "not_installed"},
+		{ErrMissingSDK, "missing_sdk"},
+		{ErrUpdateAvailable, "update_available"},
+		{ErrNoVersions, "no_versions"},
+		{ErrNetworkDisabled, "network_disabled"},
+		{ErrNetworkDeclined, "network_declined"},
+		{context.DeadlineExceeded, "timeout"},
+		{context.Canceled, "interrupted"},
+	} {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return "error"
+}
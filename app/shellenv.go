@@ -0,0 +1,75 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// the hooks switch the Go version on directory change based on the .go-version file
+// in the current directory or its closest parent, the same way as [App.Status].
+var shellHooks = map[string]string{
+	"bash": `_goversion_hook() {
+    local dir="$PWD"
+    while [ -n "$dir" ] && [ ! -f "$dir/.go-version" ]; do
+        dir="${dir%/*}"
+    done
+    if [ -f "$dir/.go-version" ]; then
+        local v
+        v="$(tr -d '[:space:]' < "$dir/.go-version")"
+        if [ -n "$v" ] && [ "$v" != "$_GOVERSION_LAST" ]; then
+            goversion use "$v" && _GOVERSION_LAST="$v"
+        fi
+    fi
+}
+case ";${PROMPT_COMMAND:-};" in
+    *";_goversion_hook;"*) ;;
+    *) PROMPT_COMMAND="_goversion_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+`,
+	"zsh": `_goversion_hook() {
+    local dir="$PWD"
+    while [ -n "$dir" ] && [ ! -f "$dir/.go-version" ]; do
+        dir="${dir%/*}"
+    done
+    if [ -f "$dir/.go-version" ]; then
+        local v
+        v="$(tr -d '[:space:]' < "$dir/.go-version")"
+        if [ -n "$v" ] && [ "$v" != "$_GOVERSION_LAST" ]; then
+            goversion use "$v" && _GOVERSION_LAST="$v"
+        fi
+    fi
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook chpwd _goversion_hook
+_goversion_hook
+`,
+	"fish": `function _goversion_hook --on-variable PWD
+    set -l dir $PWD
+    while test -n "$dir"; and not test -f "$dir/.go-version"
+        set dir (string replace -r '/[^/]*$' '' -- $dir)
+    end
+    if test -f "$dir/.go-version"
+        set -l v (string trim < "$dir/.go-version")
+        if test -n "$v"; and test "$v" != "$_goversion_last"
+            goversion use $v; and set -g _goversion_last $v
+        end
+    end
+end
+_goversion_hook
+`,
+}
+
+func (a *App) ShellEnv(shell string) error {
+	if shell == "" {
+		value, _ := a.env().LookupEnv("SHELL")
+		shell = filepath.Base(value)
+	}
+
+	hook, ok := shellHooks[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q (supported: bash, zsh, fish)", shell)
+	}
+
+	fmt.Fprint(a.Output, hook)
+	return nil
+}
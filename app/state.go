@@ -0,0 +1,262 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the names of the files in the state directory.
+const (
+	previousFile = "previous"
+	historyFile  = "history"
+	pinsFile     = "pins"
+	configFile   = "config"
+	tipRefFile   = "tip-ref"
+)
+
+// historySize is the maximum number of entries kept in the history file.
+const historySize = 20
+
+type historyEntry struct {
+	time    time.Time
+	version string
+}
+
+// previousVersion returns the version that was in use before the last switch.
+func (a *App) previousVersion() (string, error) {
+	data, err := a.readState(previousFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", errors.New("no previous version has been recorded")
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// forgetPrevious removes the previous version record if it matches the version.
+// It is best effort, just like [App.recordSwitch].
+func (a *App) forgetPrevious(version string) {
+	if prev, err := a.previousVersion(); err == nil && prev == version {
+		a.removeState(previousFile)
+	}
+}
+
+// recordSwitch saves the previous version and appends the new one to the history.
+// It is best effort: a failure to save the state must not fail the switch.
+func (a *App) recordSwitch(prev, next string) {
+	a.writeState(previousFile, []byte(prev+"\n"))
+
+	history, _ := a.readHistory()
+	history = append(history, historyEntry{time: a.now(), version: next})
+	if len(history) > historySize {
+		history = history[len(history)-historySize:]
+	}
+
+	var buf bytes.Buffer
+	for _, entry := range history {
+		fmt.Fprintf(&buf, "%s\t%s\n", entry.time.Format(time.RFC3339), entry.version)
+	}
+	a.writeState(historyFile, buf.Bytes())
+}
+
+// readHistory returns the history of switches, from oldest to newest.
+// Malformed lines are skipped.
+func (a *App) readHistory() ([]historyEntry, error) {
+	data, err := a.readState(historyFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var history []historyEntry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		ts, version, ok := strings.Cut(sc.Text(), "\t")
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			continue
+		}
+		history = append(history, historyEntry{time: t, version: version})
+	}
+
+	return history, sc.Err()
+}
+
+// readPins returns the list of pinned versions.
+func (a *App) readPins() ([]string, error) {
+	data, err := a.readState(pinsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(data)), nil
+}
+
+// writePins saves the list of pinned versions, sorted from newest to oldest.
+func (a *App) writePins(pins []string) error {
+	sort.Slice(pins, func(i, j int) bool {
+		return versionLess(pins[i], pins[j])
+	})
+	var buf bytes.Buffer
+	for _, pin := range pins {
+		buf.WriteString(pin + "\n")
+	}
+	if a.State == nil {
+		return errNoState
+	}
+	return a.State.WriteFile(pinsFile, buf.Bytes(), 0o644)
+}
+
+// readConfig reads the config file, which consists of `key = value` lines.
+// Empty lines and lines starting with # are skipped.
+func (a *App) readConfig() (map[string]string, error) {
+	data, err := a.readState(configFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: malformed line %q", configFile, n, line)
+		}
+		config[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return config, sc.Err()
+}
+
+// configBool reports whether the boolean option is enabled in the config.
+// It is a display preference, so a malformed config is reported, but doesn't fail the command.
+func (a *App) configBool(key string) bool {
+	config, err := a.readConfig()
+	if err != nil {
+		a.warnf("reading config: %v", err)
+		return false
+	}
+	value, ok := config[key]
+	if !ok {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		a.warnf("config: %s: malformed boolean %q", key, value)
+		return false
+	}
+	return enabled
+}
+
+// runPostUseHook runs the hook.post_use command from the config, if any,
+// with the new version in the GOVERSION_NEW environment variable.
+// It is best effort: a failed hook is reported, but doesn't fail (or revert) the switch.
+func (a *App) runPostUseHook(ctx context.Context, version string) {
+	config, err := a.readConfig()
+	if err != nil {
+		a.warnf("reading config: %v", err)
+		return
+	}
+
+	args, err := SplitArgs(config["hook.post_use"])
+	if err != nil {
+		a.warnf("post-use hook: %v", err)
+		return
+	}
+	if len(args) == 0 {
+		return
+	}
+
+	if a.RunCmdEnv == nil {
+		a.warnf("post-use hook: running hooks is not supported by this App")
+		return
+	}
+	if err := a.RunCmdEnv(ctx, []string{"GOVERSION_NEW=" + version}, args[0], args[1:]...); err != nil {
+		a.warnf("post-use hook failed: %v", err)
+	}
+}
+
+// tipRef returns the ref (a branch or a CL number) the current tip was built at,
+// or an empty string if it's unknown.
+func (a *App) tipRef() string {
+	data, err := a.readState(tipRefFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// recordTipRef saves the ref the current tip was built at, or forgets it if the ref is empty.
+// It is best effort, just like [App.recordSwitch].
+func (a *App) recordTipRef(ref string) {
+	if ref == "" {
+		a.removeState(tipRefFile)
+		return
+	}
+	a.writeState(tipRefFile, []byte(ref+"\n"))
+}
+
+// errNoState is returned by the commands that can't work without a state directory (e.g. pin)
+// if [App.State] is nil.
+var errNoState = errors.New("no state directory is configured")
+
+// readState reads the state file. A missing state directory (including a nil [App.State])
+// is reported as [fs.ErrNotExist], so that the callers fall back to their defaults.
+func (a *App) readState(name string) ([]byte, error) {
+	if a.State == nil {
+		return nil, fs.ErrNotExist
+	}
+	return fs.ReadFile(a.State, name)
+}
+
+// writeState saves the state file. It is best effort: if the state directory is unwritable
+// (e.g. a read-only home directory), a warning is printed once, and the command continues without persisting the state.
+func (a *App) writeState(name string, data []byte) {
+	if a.State == nil {
+		return
+	}
+	if err := a.State.WriteFile(name, data, 0o644); err != nil {
+		a.warnState(err)
+	}
+}
+
+// removeState removes the state file, best effort, just like [App.writeState].
+func (a *App) removeState(name string) {
+	if a.State == nil {
+		return
+	}
+	if err := a.State.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		a.warnState(err)
+	}
+}
+
+func (a *App) warnState(err error) {
+	if a.stateWarned {
+		return
+	}
+	a.stateWarned = true
+	a.warnf("unable to save the state, so the history and the previous version won't be updated: %v", err)
+}
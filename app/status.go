@@ -0,0 +1,139 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// versionFile is the name of the file with the version of a project (see `goversion shellenv`).
+const versionFile = ".go-version"
+
+// StatusOptions configures the output of [App.Status].
+type StatusOptions struct {
+	JSON bool // print the output in the compact JSON format (e.g. for prompt plugins).
+}
+
+// Status prints the current version and whether it matches the .go-version file
+// found in the directory or its parents. It never accesses the network, so it's fast enough for prompts.
+func (a *App) Status(ctx context.Context, dir string, opts StatusOptions) error {
+	local, err := a.localVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	pinned, err := findVersionFile(dir)
+	if err != nil {
+		return err
+	}
+	if pinned == "main" {
+		pinned = local.main
+	}
+
+	// without .go-version, any version matches.
+	match := pinned == "" || pinned == local.current
+	sdk := local.current == local.main || a.downloaded(local.current)
+	goroot := a.shadowingGOROOT(local, local.current)
+	if goroot != "" {
+		a.warnf("GOROOT=%s shadows the %s SDK, so switching versions has no effect; run `unset GOROOT` to fix it", goroot, local.current)
+	}
+
+	if opts.JSON {
+		return json.NewEncoder(a.Output).Encode(statusJSON{
+			Schema: JSONSchema,
+			Active: local.current,
+			Pinned: pinned,
+			Match:  match,
+			SDK:    sdk,
+			GOROOT: goroot,
+		})
+	}
+
+	var notes []string
+	if !match {
+		notes = append(notes, versionFile+": "+pinned)
+	}
+	if !sdk {
+		notes = append(notes, "missing SDK")
+	}
+	if goroot != "" {
+		notes = append(notes, "shadowed by GOROOT")
+	}
+
+	var extra string
+	if len(notes) > 0 {
+		extra = " (" + strings.Join(notes, ", ") + ")"
+	}
+	fmt.Fprintf(a.Output, "%s%s\n", local.current, extra)
+	return nil
+}
+
+// findVersionFile returns the version from the .go-version file in the directory or its closest parent,
+// or an empty string if there is none.
+func findVersionFile(dir string) (string, error) {
+	path, data, err := findFile(dir, versionFile)
+	if err != nil || path == "" {
+		return "", err
+	}
+	version, err := ParseVersionFile(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return version, nil
+}
+
+// ModVersion returns the version from the go.mod file in the directory or its closest parent (see [ParseGoMod]).
+func ModVersion(dir string) (string, error) {
+	path, data, err := findFile(dir, "go.mod")
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", fmt.Errorf("go.mod has not been found in %s or its parents", dir)
+	}
+	version, err := ParseGoMod(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return version, nil
+}
+
+// findFile returns the path and the contents of the named file in the directory or its closest parent,
+// or an empty path if there is none.
+func findFile(dir, name string) (string, []byte, error) {
+	for {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			return path, data, nil
+		case !errors.Is(err, fs.ErrNotExist):
+			return "", nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil, nil
+		}
+		dir = parent
+	}
+}
+
+// shadowingGOROOT returns the value of the GOROOT environment variable
+// if it points to an SDK other than the one of the version, or an empty string otherwise.
+// The main version is not checked, since its SDK path is not managed by goversion.
+func (a *App) shadowingGOROOT(local *local, version string) string {
+	goroot, ok := a.env().LookupEnv("GOROOT")
+	if !ok || goroot == "" || version == local.main {
+		return ""
+	}
+	if filepath.Clean(goroot) == filepath.Clean(a.SDK.Path("go"+version)) {
+		return ""
+	}
+	return goroot
+}
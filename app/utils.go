@@ -1,8 +1,13 @@
 package app
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	goversion "go/version"
+	"io"
 	"os"
+	"regexp"
 	"runtime"
 	"slices"
 	"sort"
@@ -14,6 +19,78 @@ func isValid(version string) bool {
 	return goversion.IsValid("go"+version) || version == "tip"
 }
 
+// binaryVersion returns the version of the go<version>[.exe] binary at the path,
+// or an empty string if it's not a Go binary. The path may be a bare name (e.g. a $GOBIN entry),
+// or an absolute or relative symlink target with either path separator.
+func binaryVersion(path string) string {
+	name := path[strings.LastIndexAny(path, `/\`)+1:]
+	version, ok := strings.CutPrefix(name, "go")
+	if !ok {
+		return ""
+	}
+	version = strings.TrimSuffix(version, ".exe")
+	if !isValid(version) {
+		return ""
+	}
+	return version
+}
+
+// goVersionRE matches the goX.Y.Z token, which may be followed by a devel suffix (e.g. go1.22-abc123).
+var goVersionRE = regexp.MustCompile(`\bgo(\d+\.\d+(?:\.\d+)?(?:(?:rc|beta)\d+)?)\b`)
+
+// parseGoVersion extracts the version from the `go version` output.
+// It tolerates devel builds, experiment annotations (e.g. X:nocoverageredesign),
+// and extra lines printed by the toolchain switching (see GOTOOLCHAIN).
+func parseGoVersion(output string) (string, bool) {
+	// prefer the `go version` line itself over the other ones (e.g. "go: downloading go1.22.0").
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "go version ") {
+			output = line
+			break
+		}
+	}
+	m := goVersionRE.FindStringSubmatch(output)
+	if m == nil || !isValid(m[1]) {
+		return "", false
+	}
+	return m[1], true
+}
+
+// SplitArgs splits the command line arguments (e.g. for `use -install-flags`) by whitespace,
+// keeping single- or double-quoted parts together, e.g. `-ldflags "-s -w"` into -ldflags and -s -w.
+func SplitArgs(s string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, s)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
 func exe() string {
 	if runtime.GOOS == "windows" {
 		return ".exe"
@@ -29,12 +106,33 @@ func cutFromPath(path, value string) string {
 	return strings.Join(newPath, string(os.PathListSeparator))
 }
 
-func latestPatches(versions []string) []string {
-	sorted := sort.SliceIsSorted(versions, func(i, j int) bool {
+// hasVersionPrefix reports whether the version starts with the prefix on a component boundary,
+// e.g. 1.2 matches 1.2, 1.2.1 and 1.2rc1, but not 1.20.
+func hasVersionPrefix(version, prefix string) bool {
+	if !strings.HasPrefix(version, prefix) {
+		return false
+	}
+	if len(version) == len(prefix) || prefix == "" {
+		return true
+	}
+	last, next := prefix[len(prefix)-1], version[len(prefix)]
+	return !isDigit(last) || !isDigit(next)
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+// sortVersions sorts the versions from newest to oldest (tip first).
+func sortVersions(versions []string) {
+	sort.Slice(versions, func(i, j int) bool {
 		return versionLess(versions[i], versions[j])
 	})
-	if !sorted {
-		panic("version list is not sorted")
+}
+
+func latestPatches(versions []string) []string {
+	// the versions may come from external data (e.g. stdin), so their order is not trusted.
+	if !sort.SliceIsSorted(versions, func(i, j int) bool { return versionLess(versions[i], versions[j]) }) {
+		versions = slices.Clone(versions)
+		sortVersions(versions)
 	}
 
 	if len(versions) <= 1 {
@@ -55,6 +153,104 @@ func latestPatches(versions []string) []string {
 	return latest
 }
 
+// latestPatch returns the latest stable patch from the versions (sorted from newest to oldest)
+// for the minor version line of the given version, or an empty string if there is none.
+func latestPatch(versions []string, version string) string {
+	if version == "tip" {
+		return ""
+	}
+	maj, _, _ := parseVersion(version)
+	for _, v := range versions {
+		if v == "tip" {
+			continue
+		}
+		if m, _, tail := parseVersion(v); m == maj && tail == "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// minorVersion returns the <major>.<minor> part of the version, e.g. 1.21 for 1.21.6 and 1.21rc1.
+func minorVersion(version string) string {
+	if i := strings.IndexAny(version, "br"); i > 0 { // beta or rc.
+		version = version[:i]
+	}
+	if p := strings.SplitN(version, ".", 3); len(p) == 3 {
+		return p[0] + "." + p[1]
+	}
+	return version
+}
+
+// channel returns the release channel of the version: stable, rc, beta or tip.
+// It's the same as the stable flag of go.dev, since only stable releases have no prerelease tail.
+func channel(version string) string {
+	if version == "tip" {
+		return "tip"
+	}
+	_, _, tail := parseVersion(version)
+	switch {
+	case strings.HasPrefix(tail, "rc"):
+		return "rc"
+	case strings.HasPrefix(tail, "beta"):
+		return "beta"
+	}
+	return "stable"
+}
+
+// supportedPatches returns the latest stable patches of the two newest minor versions
+// from the versions (sorted from newest to oldest), since Go supports only the two latest major releases.
+func supportedPatches(versions []string) []string {
+	var patches []string
+	for _, v := range versions {
+		if _, _, tail := parseVersion(v); v == "tip" || tail != "" {
+			continue
+		}
+		if len(patches) > 0 && minorVersion(patches[len(patches)-1]) == minorVersion(v) {
+			continue
+		}
+		if patches = append(patches, v); len(patches) == 2 {
+			break
+		}
+	}
+	return patches
+}
+
+// patchesBehind returns the number of stable patches from the versions
+// that are newer than the given version within its minor version line.
+func patchesBehind(versions []string, version string) int {
+	if version == "tip" {
+		return 0
+	}
+	maj, _, _ := parseVersion(version)
+	var n int
+	for _, v := range versions {
+		if v == "tip" {
+			continue
+		}
+		if m, _, tail := parseVersion(v); m == maj && tail == "" && versionNewer(v, version) {
+			n++
+		}
+	}
+	return n
+}
+
+// compareVersion returns whether the version is newer, the same or older than the reference.
+func compareVersion(version, reference string) string {
+	switch {
+	case version == reference:
+		return "same"
+	case versionNewer(version, reference):
+		return "newer"
+	}
+	return "older"
+}
+
+// versionNewer reports whether a is strictly newer than b.
+func versionNewer(a, b string) bool {
+	return versionLess(a, b) && !versionLess(b, a)
+}
+
 // the following code is a modified version of the functions from
 // https://github.com/golang/website/blob/master/internal/dl/dl.go
 
@@ -74,13 +270,22 @@ func versionLess(a, b string) bool {
 			} else if tb == "" {
 				return false
 			}
-			return ta >= tb
+			// compare the prerelease numbers as integers, so that rc10 is newer than rc2.
+			kinda, na := splitTail(ta)
+			kindb, nb := splitTail(tb)
+			if kinda == kindb {
+				return na >= nb
+			}
+			return kinda >= kindb
 		}
 		return mina >= minb
 	}
 	return maja >= majb
 }
 
+// parseVersion splits the version into its minor and patch numbers and the prerelease tail,
+// e.g. 1.21.10 into 21, 10 and "", and 1.22rc1 into 22, 0 and "rc1".
+// Note that, as in the original code, maj is the minor number of Go 1 and min is the patch number.
 func parseVersion(v string) (maj, min int, tail string) {
 	if i := strings.Index(v, "beta"); i > 0 {
 		tail = v[i:]
@@ -98,3 +303,91 @@ func parseVersion(v string) (maj, min int, tail string) {
 	min, _ = strconv.Atoi(p[1])
 	return
 }
+
+// splitTail splits the prerelease tail into its kind and number, e.g. rc10 into "rc" and 10.
+func splitTail(tail string) (kind string, n int) {
+	i := strings.IndexFunc(tail, func(r rune) bool { return isDigit(byte(r)) })
+	if i < 0 {
+		return tail, 0
+	}
+	n, _ = strconv.Atoi(tail[i:])
+	return tail[:i], n
+}
+
+// formatSize formats the size in bytes using the binary units (e.g. 1.5 MiB).
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// versionIDRE matches the id of a version section on the go.dev/dl HTML page, e.g. <div class="toggle" id="go1.21.6">.
+var versionIDRE = regexp.MustCompile(`\bid="go([^"]+)"`)
+
+// scrapeVersions extracts the versions from the go.dev/dl HTML page, sorted from newest to oldest
+// (the page lists unstable releases after the stable ones).
+// If the filter is not empty, versions that don't link a matching file are skipped.
+func scrapeVersions(r io.Reader, filter fileFilter) ([]string, error) {
+	page, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := versionIDRE.FindAllSubmatch(page, -1)
+	if len(matches) == 0 {
+		return nil, errors.New("no versions have been found on the go.dev HTML page")
+	}
+
+	versions := []string{"tip"}
+	for _, match := range matches {
+		version := string(match[1])
+		if !isValid(version) || slices.Contains(versions, version) {
+			continue
+		}
+		if filter != (fileFilter{}) && !slices.ContainsFunc(filter.fileNames(version), func(name string) bool {
+			return bytes.Contains(page, []byte("/dl/"+name+`"`))
+		}) {
+			continue
+		}
+		versions = append(versions, version)
+	}
+
+	sortVersions(versions)
+	return versions, nil
+}
+
+// fileFilter matches the files of a version on go.dev; the zero value matches nothing.
+type fileFilter struct {
+	os, arch string
+	kind     string // archive, installer or source.
+}
+
+// match reports whether a file with the given os, arch and kind (as in the go.dev JSON) matches the filter.
+// Source archives are platform-independent.
+func (f fileFilter) match(goos, goarch, kind string) bool {
+	if f.kind == "source" {
+		return kind == "source"
+	}
+	return f.os == goos && f.arch == goarch && f.kind == kind
+}
+
+// fileNames returns the names of the version's files that match the filter,
+// e.g. go1.21.6.windows-arm64.zip for windows/arm64 archives.
+func (f fileFilter) fileNames(version string) []string {
+	base := "go" + version + "." + f.os + "-" + f.arch
+	switch f.kind {
+	case "source":
+		return []string{"go" + version + ".src.tar.gz"}
+	case "installer":
+		return []string{base + ".msi", base + ".pkg"}
+	default:
+		return []string{base + ".tar.gz", base + ".zip"}
+	}
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"os"
+	"sort"
 	"strings"
 	"testing"
 
@@ -18,6 +19,82 @@ func Test_cutFromPath(t *testing.T) {
 	assert.Equal[E](t, got, join("foo", "baz"))
 }
 
+func Test_parseGoVersion(t *testing.T) {
+	tests := map[string]struct {
+		output string
+		want   string
+		ok     bool
+	}{
+		"release":      {"go version go1.22.1 linux/amd64\n", "1.22.1", true},
+		"rc":           {"go version go1.23rc1 darwin/arm64\n", "1.23rc1", true},
+		"devel":        {"go version devel go1.22-abc123 Tue Jan 2 15:04:05 2024 +0000 linux/amd64\n", "1.22", true},
+		"toolchain":    {"go: downloading go1.22.0 (linux/amd64)\ngo version go1.21.6 linux/amd64\n", "1.21.6", true},
+		"experiment":   {"go version go1.21.6 X:nocoverageredesign linux/amd64\n", "1.21.6", true},
+		"boringcrypto": {"go version go1.21.6 X:boringcrypto,nocoverageredesign linux/amd64\n", "1.21.6", true},
+		"vendor":       {"go version go1.22.1-1 linux/amd64\n", "1.22.1", true},
+		"crlf":         {"go: downloading go1.22.0 (windows/amd64)\r\n  go version go1.21.6 windows/amd64\r\n", "1.21.6", true},
+		"malformed":    {"go version devel +b7a03d8 linux/amd64\n", "", false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := parseGoVersion(tt.output)
+			assert.Equal[E](t, ok, tt.ok)
+			assert.Equal[E](t, got, tt.want)
+		})
+	}
+}
+
+func Test_binaryVersion(t *testing.T) {
+	tests := map[string]struct {
+		path string
+		want string
+	}{
+		"name":         {"go1.18", "1.18"},
+		"name (exe)":   {"go1.18.exe", "1.18"},
+		"absolute":     {"/home/gopher/go/bin/go1.21.6", "1.21.6"},
+		"relative":     {"../bin/go1.21.6", "1.21.6"},
+		"windows":      {`C:\Users\gopher\go\bin\go1.22rc1.exe`, "1.22rc1"},
+		"tip":          {"/home/gopher/go/bin/gotip", "tip"},
+		"go":           {"go", ""},
+		"temp file":    {"go.tmp", ""},
+		"dotfile":      {".go1.18", ""},
+		"other tool":   {"/home/gopher/go/bin/gopls", ""},
+		"no go prefix": {"1.18", ""},
+		"empty":        {"", ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal[E](t, binaryVersion(tt.path), tt.want)
+		})
+	}
+}
+
+func Test_hasVersionPrefix(t *testing.T) {
+	tests := []struct {
+		version, prefix string
+		want            bool
+	}{
+		{"1.2", "1.2", true},
+		{"1.2.1", "1.2", true},
+		{"1.2rc1", "1.2", true},
+		{"1.20", "1.2", false},
+		{"1.21.3", "1.2", false},
+		{"1.21.3", "1.21", true},
+		{"1.210.1", "1.21", false},
+		{"1.21.3", "1.21.", true},
+		{"1.21.3", "1.", true},
+		{"1.21.3", "", true},
+		{"tip", "1.21", false},
+	}
+
+	for _, tt := range tests {
+		got := hasVersionPrefix(tt.version, tt.prefix)
+		assert.Equal[E](t, got, tt.want, "%s with prefix %q", tt.version, tt.prefix)
+	}
+}
+
 func Test_latestPatches(t *testing.T) {
 	got := latestPatches([]string{
 		"tip",
@@ -33,4 +110,123 @@ func Test_latestPatches(t *testing.T) {
 		"1.20rc3",
 		"1.19.3",
 	})
+
+	got = latestPatches([]string{"1.20.1", "1.21.0", "1.20.2"})
+	assert.Equal[E](t, got, []string{"1.21.0", "1.20.2"})
+}
+
+func Test_versionLess_sort(t *testing.T) {
+	versions := []string{"1.21.2", "1.21rc2", "1.21.10", "1.21.0", "tip", "1.21rc10", "1.21.9", "1.20.14"}
+	sort.Slice(versions, func(i, j int) bool {
+		return versionLess(versions[i], versions[j])
+	})
+	assert.Equal[E](t, versions, []string{"tip", "1.21.10", "1.21.9", "1.21.2", "1.21.0", "1.21rc10", "1.21rc2", "1.20.14"})
+}
+
+func Test_supportedPatches(t *testing.T) {
+	got := supportedPatches([]string{"tip", "1.23rc1", "1.22.1", "1.22.0", "1.22rc2", "1.21.8", "1.21.7", "1.20.14"})
+	assert.Equal[E](t, got, []string{"1.22.1", "1.21.8"})
+}
+
+func Test_versionNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"tip", "1.21", true},
+		{"1.21", "tip", false},
+		{"1.21", "1.20", true},
+		{"1.20.1", "1.20", true},
+		{"1.20", "1.20rc1", true},
+		{"1.20rc2", "1.20rc1", true},
+		{"1.20rc1", "1.20", false},
+		{"1.20", "1.20", false},
+		{"1.19.13", "1.20", false},
+		{"1.21.10", "1.21.2", true},
+		{"1.21.2", "1.21.10", false},
+		{"1.21.10", "1.21.9", true},
+		{"1.22rc10", "1.22rc2", true},
+		{"1.22rc2", "1.22rc10", false},
+		{"1.22rc1", "1.22beta2", true},
+	}
+
+	for _, tt := range tests {
+		got := versionNewer(tt.a, tt.b)
+		assert.Equal[E](t, got, tt.want, "%s newer than %s", tt.a, tt.b)
+	}
+}
+
+func Test_minorVersion(t *testing.T) {
+	tests := map[string]string{
+		"tip":       "tip",
+		"1":         "1",
+		"1.2.2":     "1.2",
+		"1.20":      "1.20",
+		"1.21.6":    "1.21",
+		"1.21rc1":   "1.21",
+		"1.18beta2": "1.18",
+	}
+	for version, want := range tests {
+		assert.Equal[E](t, minorVersion(version), want, version)
+	}
+}
+
+func Test_channel(t *testing.T) {
+	tests := map[string]string{
+		"tip":       "tip",
+		"1.21.6":    "stable",
+		"1.20":      "stable",
+		"1.21rc1":   "rc",
+		"1.18beta2": "beta",
+	}
+	for version, want := range tests {
+		assert.Equal[E](t, channel(version), want, version)
+	}
+}
+
+func Test_formatSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{250 << 20, "250.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		got := formatSize(tt.size)
+		assert.Equal[E](t, got, tt.want, "%d bytes", tt.size)
+	}
+}
+
+func Test_scrapeVersions(t *testing.T) {
+	const page = `
+<div class="toggleVisible" id="go1.22.1">
+  <a href="/dl/go1.22.1.linux-amd64.tar.gz">go1.22.1.linux-amd64.tar.gz</a>
+</div>
+<div class="toggle" id="go1.16">
+  <a href="/dl/go1.16.windows-arm64.zip">go1.16.windows-arm64.zip</a>
+</div>
+<div class="toggle" id="go1.23rc1">
+  <a href="/dl/go1.23rc1.linux-amd64.tar.gz">go1.23rc1.linux-amd64.tar.gz</a>
+</div>
+`
+	got, err := scrapeVersions(strings.NewReader(page), fileFilter{})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, got, []string{"tip", "1.23rc1", "1.22.1", "1.16"})
+
+	got, err = scrapeVersions(strings.NewReader(page), fileFilter{os: "windows", arch: "arm64", kind: "archive"})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, got, []string{"tip", "1.16"})
+
+	got, err = scrapeVersions(strings.NewReader(page), fileFilter{os: "windows", arch: "arm64", kind: "installer"})
+	assert.NoErr[F](t, err)
+	assert.Equal[E](t, got, []string{"tip"})
+
+	_, err = scrapeVersions(strings.NewReader("<html></html>"), fileFilter{})
+	assert.Equal[E](t, err.Error(), "no versions have been found on the go.dev HTML page")
 }
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// WhichOptions configures the output of [App.Which].
+type WhichOptions struct {
+	All  bool // print the wrappers of all installed versions, not just the current one.
+	JSON bool // print the output in the JSON format.
+}
+
+// Which prints the path of the go binary of the current version,
+// or, with [WhichOptions.All], the paths of all go<version> wrappers managed by goversion.
+func (a *App) Which(ctx context.Context, opts WhichOptions) error {
+	local, err := a.localVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	out := whichJSON{Schema: JSONSchema, Binaries: []binaryJSON{}}
+	if local.linked {
+		// in the copy mode, $GOBIN/go is a regular file, so there's no link to report.
+		if target, err := a.GoBin.Readlink("go" + exe()); err == nil {
+			if !filepath.IsAbs(target) {
+				target = a.GoBin.Path(target) // relative to $GOBIN itself.
+			}
+			out.Link = target
+		}
+	}
+
+	if !opts.All {
+		path := a.GoBin.Path("go" + local.current + exe())
+		if local.current == local.main {
+			output, err := a.runMainGo(ctx, "env", "GOROOT")
+			if err != nil {
+				return err
+			}
+			path = filepath.Join(strings.TrimSpace(output), "bin", "go"+exe())
+		}
+		out.Binaries = append(out.Binaries, binaryJSON{Version: local.current, Path: path, Current: true})
+	} else {
+		for _, version := range local.list {
+			if !a.managed(version) {
+				continue // the main version has no wrapper.
+			}
+			out.Binaries = append(out.Binaries, binaryJSON{
+				Version: version,
+				Path:    a.GoBin.Path("go" + version + exe()),
+				Current: version == local.current,
+			})
+		}
+	}
+
+	if opts.JSON {
+		return a.printJSON(out)
+	}
+
+	if !opts.All {
+		fmt.Fprintln(a.Output, out.Binaries[0].Path)
+		return nil
+	}
+
+	var maxLen int
+	for _, b := range out.Binaries {
+		maxLen = max(maxLen, len(b.Version))
+	}
+	for _, b := range out.Binaries {
+		prefix := " "
+		if b.Current {
+			prefix = "*"
+		}
+		fmt.Fprintf(a.Output, "%s %-*s %s\n", prefix, maxLen, b.Version, b.Path)
+	}
+	if out.Link != "" {
+		fmt.Fprintf(a.Output, "\n%s -> %s\n", a.GoBin.Path("go"+exe()), out.Link)
+	}
+	return nil
+}
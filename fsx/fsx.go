@@ -14,6 +14,9 @@ type FS interface {
 	RemoveAll(name string) error
 	Symlink(name, link string) error
 	Readlink(name string) (string, error)
+	Lstat(name string) (fs.FileInfo, error)
+	Path(name string) string
+	WriteFile(name string, data []byte, perm fs.FileMode) error
 }
 
 type dirFS struct {
@@ -27,8 +30,40 @@ func DirFS(path ...string) FS {
 	return dirFS{os.DirFS(dir), dir}
 }
 
-func (d dirFS) Remove(name string) error             { return os.Remove(d.join(name)) }
-func (d dirFS) RemoveAll(name string) error          { return os.RemoveAll(d.join(name)) }
-func (d dirFS) Symlink(name, link string) error      { return os.Symlink(d.join(name), d.join(link)) }
-func (d dirFS) Readlink(name string) (string, error) { return os.Readlink(d.join(name)) }
-func (d dirFS) join(name string) string              { return filepath.Join(d.Dir, name) }
+func (d dirFS) Remove(name string) error               { return os.Remove(d.join(name)) }
+func (d dirFS) RemoveAll(name string) error            { return os.RemoveAll(d.join(name)) }
+func (d dirFS) Symlink(name, link string) error        { return os.Symlink(d.join(name), d.join(link)) }
+func (d dirFS) Readlink(name string) (string, error)   { return os.Readlink(d.join(name)) }
+func (d dirFS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(d.join(name)) }
+func (d dirFS) Path(name string) string                { return d.join(name) }
+func (d dirFS) join(name string) string                { return filepath.Join(d.Dir, name) }
+
+// WriteFile writes the data to the named file atomically,
+// so that an interrupted write never leaves a partial file behind.
+func (d dirFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	path := d.join(name)
+	// the directory may not exist yet (e.g. the state directory on the first run).
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // no-op if the rename below succeeds.
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
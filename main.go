@@ -2,7 +2,10 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -13,6 +16,8 @@ import (
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
 	"go-simpler.org/goversion/app"
@@ -23,16 +28,87 @@ const usage = `Usage: goversion [flags] <command> [command flags]
 
 Commands:
     use main              switch to the main Go version
+    use -                 switch to the previously used Go version
     use <version>         switch to the specified Go version (will be installed if not exists)
+    use mod               switch to the version from go.mod (the toolchain directive, or the go one)
+    use <minor>@latest    switch to the latest patch of the specified Go version (e.g. 1.21@latest)
+    use tip@<ref>         switch to tip built at the branch or CL (rebuilt only if the ref changes)
+        -fail-if-missing  fail if the version is not installed instead of installing it
+        -download-only    install the version (both binary and SDK) but don't switch to it
+        -print-only       only print the version the spec resolves to (e.g. for 1.21@latest)
+        -replace-minor    remove the other installed patches of the same minor version (except pinned ones)
+        -from-file=<file> read the version from the file (the first line that is not empty or a # comment)
+        -wait=<dur>       retry installing the version for the duration (e.g. on release day)
+        -install-flags    pass the flags to go install golang.org/dl/go<version> (e.g. -install-flags="-x -v")
     ls                    print the list of installed Go versions
         -a (-all)         print also available versions from go.dev
-        -only=<prefix>    print only versions starting with the prefix
+        -prefer-installed print installed versions before the ones not installed
+        -grouped          print versions under a header per minor version
+        -only=<prefix>    print only versions starting with the prefix (1.2 matches 1.2.x, not 1.20)
         -only=latest      print only the latest patch for each version
+        -minor            the same as -only=latest
+        -prerelease=beta  print only beta releases
+        -prerelease=rc    print only release candidates
+        -since=<version>  print only versions newer than the specified one
+        -until=<version>  print only versions older than or equal to the specified one
+        -compare=<ver>    mark versions as newer, same or older than the specified one
+        -active-only      print only the current version
+        -active-first     print the current version first (or set ls.active_first = true in the config)
+        -duplicates       print only minor versions with multiple patches installed (with SDK sizes)
+        -no-main          hide the main version (unless it's also installed by goversion)
+        -require-sdk      exit with code 4 if the SDK of the current version is missing
+        -outdated         mark installed versions that have a newer patch available (exit code 3 if any)
+        -sdk-path         print also the SDK path of installed versions
+        -from-stdin       read available versions from stdin in the go.dev JSON format (implies -a)
+        -porcelain        print a stable tab-separated output for scripts
+        -json             print the output in the JSON format
+        -sizes            with -json, include the SDK size of installed versions in bytes
+        -security         mark the latest patches of the supported versions as recommended (implies -a)
+        -os=<os>          print only versions that ship a file for the OS (implies -a)
+        -arch=<arch>      print only versions that ship a file for the architecture (implies -a)
+        -kind=<kind>      the kind of the file for -os/-arch: archive (default), installer, source
+    info <version>        print the status of the specified Go version (installed, SDK path and size, etc.)
+        -outdated         check whether a newer patch is available
+        -json             print the output in the JSON format
+    status                print the current version and whether it matches .go-version (no network access)
+        -json             print the output in the compact JSON format (e.g. for prompt plugins)
     rm <version>          remove the specified Go version (both binary and SDK)
+        -f (-force)       remove the version even if it is pinned
+        -no-switch        fail if the version is in use instead of switching to the main version
+    rm -older-than=<age>  remove versions whose SDK hasn't been modified for the age (e.g. 90d or 720h)
+        -dry-run          only print the versions that would be removed
+    rm -keep=<n> [minor]  remove all but the newest n patches of the minor version (or of each one)
+        -dry-run          only print the versions that would be removed
+    reinstall <version>   remove the SDK of the specified Go version and download it again
+    pin                   print the list of pinned Go versions
+    pin <version>         pin the specified Go version to protect it from removal
+    unpin <version>       unpin the specified Go version
+    history               print the history of recent switches
+    which                 print the path of the go binary of the current version
+        -all              print the paths of all go<version> wrappers and the target of $GOBIN/go
+        -json             print the output in the JSON format
+    files <version>       print the downloads of the version on go.dev (file, platform, kind, size, SHA256)
+        -json             print the output in the JSON format
+    env [version]         print GOROOT and PATH for the version (the current one by default) in the dotenv format
+        -env-file=<file>  write them to the file instead (e.g. for a later stage of a Docker build)
+    shellenv [shell]      print a shell hook that switches versions based on .go-version (bash, zsh, fish)
+    bootstrap             check that this machine can install Go versions (toolchain, $PATH, golang.org/dl)
+    config-dir            print the directory where goversion stores its state (pins, history, etc.)
+    selfupdate            update goversion itself to the latest release
+        -check            only report whether an update is available (exit code 3 if so)
 
 Flags:
     -h (-help)            print this message and quit
     -v (-version)         print the version of goversion itself and quit
+    -json                 with -v, print the version in the JSON format
+    -gobin=<dir>          use the directory instead of $GOBIN for this invocation
+    -output=<file>        write the output to the file instead of stdout (e.g. ls -json reports)
+    -timeout=<dur>        the timeout of each network request (default 1m, 0 disables it)
+    -timeout-total=<dur>  the timeout of the whole command, including downloads (no limit by default)
+    -html-fallback        scrape the go.dev/dl HTML page if the JSON endpoint fails
+    -link-mode=<mode>     how to switch $GOBIN/go: symlink (default) or copy (also GOVERSION_NO_SYMLINK=copy)
+    -confirm-network      ask before accessing the network (go.dev, go install, SDK downloads)
+    -min-tls=<version>    the minimum TLS version of go.dev requests: 1.2 or 1.3 (Go's default if unset)
 `
 
 var version = "dev" // injected at build time.
@@ -45,9 +121,27 @@ func main() {
 		case errors.Is(err, flag.ErrHelp):
 			fmt.Printf("%s", usage)
 			os.Exit(0)
+		case errors.Is(err, app.ErrUpdateAvailable):
+			os.Exit(3)
+		case errors.Is(err, app.ErrMissingSDK):
+			os.Exit(4)
 		case errors.As(err, &exitErr):
 			code := exitErr.ExitCode()
 			os.Exit(code)
+		case errors.As(err, new(jsonError)):
+			code := app.ErrorCode(err)
+			if errors.As(err, new(usageError)) {
+				code = "usage"
+			}
+			_ = json.NewEncoder(os.Stdout).Encode(struct {
+				Schema int    `json:"schema"`
+				Error  string `json:"error"`
+				Code   string `json:"code"`
+			}{app.JSONSchema, err.Error(), code})
+			if code == "usage" {
+				os.Exit(2)
+			}
+			os.Exit(1)
 		case errors.As(err, new(usageError)):
 			fmt.Printf("Error: %v\n\n%s", err, usage)
 			os.Exit(2)
@@ -58,7 +152,7 @@ func main() {
 	}
 }
 
-func run() error {
+func run() (err error) {
 	fset := flag.NewFlagSet("", flag.ContinueOnError)
 	fset.SetOutput(io.Discard)
 
@@ -66,11 +160,58 @@ func run() error {
 	fset.BoolVar(&printVersion, "v", false, "")
 	fset.BoolVar(&printVersion, "version", false, "")
 
+	var printJSON bool
+	fset.BoolVar(&printJSON, "json", false, "")
+
+	var gobinFlag string
+	fset.StringVar(&gobinFlag, "gobin", "", "")
+
+	var outputFlag string
+	fset.StringVar(&outputFlag, "output", "", "")
+
+	var htmlFallback bool
+	fset.BoolVar(&htmlFallback, "html-fallback", false, "")
+
+	var timeout, timeoutTotal time.Duration
+	fset.DurationVar(&timeout, "timeout", time.Minute, "")
+	fset.DurationVar(&timeoutTotal, "timeout-total", 0, "")
+
+	var linkMode string
+	fset.StringVar(&linkMode, "link-mode", "", "")
+
+	var minTLS string
+	fset.StringVar(&minTLS, "min-tls", "", "")
+
+	var confirmNetwork bool
+	fset.BoolVar(&confirmNetwork, "confirm-network", false, "")
+
 	if err := fset.Parse(os.Args[1:]); err != nil {
 		return usageError{err}
 	}
 
+	if linkMode == "" && os.Getenv("GOVERSION_NO_SYMLINK") == "copy" {
+		linkMode = "copy"
+	}
+	switch linkMode {
+	case "", "symlink", "copy":
+	default:
+		return usageError{fmt.Errorf("-link-mode: unknown mode %q (supported: symlink, copy)", linkMode)}
+	}
+
+	client, err := newClient(minTLS)
+	if err != nil {
+		return usageError{err}
+	}
+
 	if printVersion {
+		if printJSON {
+			return json.NewEncoder(os.Stdout).Encode(struct {
+				Schema  int    `json:"schema"`
+				Version string `json:"version"`
+				OS      string `json:"os"`
+				Arch    string `json:"arch"`
+			}{app.JSONSchema, version, runtime.GOOS, runtime.GOARCH})
+		}
 		fmt.Printf("goversion version %s %s/%s\n", version, runtime.GOOS, runtime.GOARCH)
 		return nil
 	}
@@ -86,17 +227,49 @@ func run() error {
 	}
 
 	gobin, ok := os.LookupEnv("GOBIN")
-	if !ok {
+	switch {
+	case gobinFlag != "":
+		// override $GOBIN for this invocation only, including the `go install` subprocess.
+		if info, err := os.Stat(gobinFlag); err != nil || !info.IsDir() {
+			return usageError{fmt.Errorf("-gobin: %q is not a directory", gobinFlag)}
+		}
+		gobin = gobinFlag
+		os.Setenv("GOBIN", gobin)
+	case !ok:
 		gobin = filepath.Join(home, "go", "bin")
 		os.Setenv("GOBIN", gobin)
 	}
 
-	app := app.App{
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return err
+	}
+
+	var output io.Writer = os.Stdout
+	if outputFlag != "" {
+		var w *outputFile
+		if w, err = createOutput(outputFlag); err != nil {
+			return err
+		}
+		output = w
+		defer func() {
+			if cerr := w.Close(); err == nil && cerr != nil {
+				err = fmt.Errorf("writing %s: %w", outputFlag, cerr)
+			}
+		}()
+	}
+
+	a := app.App{
 		// TODO: make sure it works on Windows;
 		// see https://github.com/golang/go/issues/44279 for details.
-		GoBin:  fsx.DirFS(gobin),
-		SDK:    fsx.DirFS(home, "sdk"), // TODO: update when https://github.com/golang/go/issues/26520 is closed.
-		Output: os.Stdout,
+		GoBin:   fsx.DirFS(gobin),
+		SDK:     fsx.DirFS(home, "sdk"), // TODO: update when https://github.com/golang/go/issues/26520 is closed.
+		State:   fsx.DirFS(configDir, "goversion"),
+		Input:   os.Stdin,
+		Output:  output,
+		Errors:  os.Stderr,
+		Env:     app.OSEnv{},
+		Version: version,
 		RunCmd: func(ctx context.Context, name string, args ...string) error {
 			cmd := exec.CommandContext(ctx, name, args...)
 			cmd.Stdout = os.Stdout
@@ -108,47 +281,405 @@ func run() error {
 			out, err := cmd.Output()
 			return string(out), err
 		},
-		Requester: &http.Client{Timeout: time.Minute},
+		RunCmdEnv: func(ctx context.Context, env []string, name string, args ...string) error {
+			cmd := exec.CommandContext(ctx, name, args...)
+			cmd.Env = append(os.Environ(), env...)
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stdout
+			return cmd.Run()
+		},
+		Requester:    client,
+		Timeout:      timeout,
+		HTMLFallback: htmlFallback,
+		CopyBinary:   linkMode == "copy",
+		Now:          time.Now,
+	}
+
+	if confirmNetwork {
+		a.ConfirmNetwork = confirmFunc(os.Stdin, os.Stderr)
 	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
+	if timeoutTotal > 0 {
+		// each network request is still bounded by -timeout as well.
+		var cancel context.CancelFunc
+		cause := fmt.Errorf("timed out after %s in total: %w", timeoutTotal, context.DeadlineExceeded)
+		ctx, cancel = context.WithTimeoutCause(ctx, timeoutTotal, cause)
+		defer cancel()
+	}
+
 	switch cmd, cmdArgs := args[0], args[1:]; cmd {
 	case "use":
-		if len(cmdArgs) == 0 {
+		fset := flag.NewFlagSet("", flag.ContinueOnError)
+		fset.SetOutput(io.Discard)
+
+		var opts app.UseOptions
+		fset.BoolVar(&opts.FailIfMissing, "fail-if-missing", false, "")
+		fset.BoolVar(&opts.DownloadOnly, "download-only", false, "")
+		fset.BoolVar(&opts.ReplaceMinor, "replace-minor", false, "")
+		fset.BoolVar(&opts.PrintOnly, "print-only", false, "")
+		fset.DurationVar(&opts.Wait, "wait", 0, "")
+
+		var installFlags string
+		fset.StringVar(&installFlags, "install-flags", "", "")
+
+		var fromFile string
+		fset.StringVar(&fromFile, "from-file", "", "")
+
+		if err := fset.Parse(cmdArgs); err != nil {
+			return usageError{err}
+		}
+		if opts.InstallFlags, err = app.SplitArgs(installFlags); err != nil {
+			return usageError{fmt.Errorf("-install-flags: %w", err)}
+		}
+		if fromFile != "" {
+			if fset.NArg() > 0 {
+				return usageError{errors.New("-from-file cannot be used with a version")}
+			}
+			data, err := os.ReadFile(fromFile)
+			if err != nil {
+				return err
+			}
+			version, err := app.ParseVersionFile(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", fromFile, err)
+			}
+			return a.Use(ctx, version, opts)
+		}
+		if fset.NArg() == 0 {
 			return usageError{errors.New("no version has been specified")}
 		}
-		return app.Use(ctx, cmdArgs[0])
+		if fset.Arg(0) == "mod" {
+			wd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			version, err := app.ModVersion(wd)
+			if err != nil {
+				return err
+			}
+			return a.Use(ctx, version, opts)
+		}
+		return a.Use(ctx, fset.Arg(0), opts)
 
 	case "ls":
 		fset := flag.NewFlagSet("", flag.ContinueOnError)
 		fset.SetOutput(io.Discard)
 
-		var printAll bool
-		fset.BoolVar(&printAll, "a", false, "")
-		fset.BoolVar(&printAll, "all", false, "")
+		var opts app.ListOptions
+		fset.BoolVar(&opts.All, "a", false, "")
+		fset.BoolVar(&opts.All, "all", false, "")
+		fset.BoolVar(&opts.PreferInstalled, "prefer-installed", false, "")
+		fset.BoolVar(&opts.Grouped, "grouped", false, "")
+		fset.StringVar(&opts.Only, "only", "", "")
+		fset.BoolVar(&opts.Minor, "minor", false, "")
+		fset.StringVar(&opts.Prerelease, "prerelease", "", "")
+		fset.StringVar(&opts.Since, "since", "", "")
+		fset.StringVar(&opts.Until, "until", "", "")
+		fset.StringVar(&opts.Compare, "compare", "", "")
+		fset.BoolVar(&opts.ActiveOnly, "active-only", false, "")
+		fset.BoolVar(&opts.ActiveFirst, "active-first", false, "")
+		fset.BoolVar(&opts.NoMain, "no-main", false, "")
+		fset.BoolVar(&opts.Duplicates, "duplicates", false, "")
+		fset.BoolVar(&opts.RequireSDK, "require-sdk", false, "")
+		fset.BoolVar(&opts.Outdated, "outdated", false, "")
+		fset.BoolVar(&opts.SDKPath, "sdk-path", false, "")
+		fset.BoolVar(&opts.FromStdin, "from-stdin", false, "")
+		fset.BoolVar(&opts.Porcelain, "porcelain", false, "")
+		fset.BoolVar(&opts.JSON, "json", false, "")
+		fset.BoolVar(&opts.Sizes, "sizes", false, "")
+		fset.BoolVar(&opts.Security, "security", false, "")
+		fset.StringVar(&opts.OS, "os", "", "")
+		fset.StringVar(&opts.Arch, "arch", "", "")
+		fset.StringVar(&opts.Kind, "kind", "", "")
 
-		var printOnly string
-		fset.StringVar(&printOnly, "only", "", "")
+		if err := fset.Parse(cmdArgs); err != nil {
+			return withJSON(usageError{err}, opts.JSON)
+		}
+		return withJSON(a.List(ctx, opts), opts.JSON)
+
+	case "info":
+		fset := flag.NewFlagSet("", flag.ContinueOnError)
+		fset.SetOutput(io.Discard)
+
+		var opts app.InfoOptions
+		fset.BoolVar(&opts.Outdated, "outdated", false, "")
+		fset.BoolVar(&opts.JSON, "json", false, "")
 
 		if err := fset.Parse(cmdArgs); err != nil {
-			return usageError{err}
+			return withJSON(usageError{err}, opts.JSON)
+		}
+		if fset.NArg() == 0 {
+			return withJSON(usageError{errors.New("no version has been specified")}, opts.JSON)
+		}
+		return withJSON(a.Info(ctx, fset.Arg(0), opts), opts.JSON)
+
+	case "status":
+		fset := flag.NewFlagSet("", flag.ContinueOnError)
+		fset.SetOutput(io.Discard)
+
+		var opts app.StatusOptions
+		fset.BoolVar(&opts.JSON, "json", false, "")
+
+		if err := fset.Parse(cmdArgs); err != nil {
+			return withJSON(usageError{err}, opts.JSON)
 		}
-		return app.List(ctx, printAll, printOnly)
+		wd, err := os.Getwd()
+		if err != nil {
+			return withJSON(err, opts.JSON)
+		}
+		return withJSON(a.Status(ctx, wd, opts), opts.JSON)
 
 	case "rm":
+		fset := flag.NewFlagSet("", flag.ContinueOnError)
+		fset.SetOutput(io.Discard)
+
+		var opts app.RemoveOptions
+		fset.BoolVar(&opts.Force, "f", false, "")
+		fset.BoolVar(&opts.Force, "force", false, "")
+		fset.BoolVar(&opts.NoSwitch, "no-switch", false, "")
+		fset.BoolVar(&opts.DryRun, "dry-run", false, "")
+
+		var olderThan string
+		fset.StringVar(&olderThan, "older-than", "", "")
+		fset.IntVar(&opts.Keep, "keep", 0, "")
+
+		if err := fset.Parse(cmdArgs); err != nil {
+			return usageError{err}
+		}
+		var keep bool
+		fset.Visit(func(f *flag.Flag) { keep = keep || f.Name == "keep" })
+		if keep {
+			if olderThan != "" {
+				return usageError{errors.New("-keep cannot be used with -older-than")}
+			}
+			if opts.Keep < 1 {
+				return usageError{errors.New("-keep: the number of patches must be positive")}
+			}
+			return a.RemoveOldPatches(ctx, fset.Arg(0), opts)
+		}
+		if olderThan != "" {
+			if opts.OlderThan, err = parseAge(olderThan); err != nil {
+				return usageError{fmt.Errorf("-older-than: %w", err)}
+			}
+			return a.RemoveUnused(ctx, opts)
+		}
+		if opts.DryRun {
+			return usageError{errors.New("-dry-run can only be used with -older-than or -keep")}
+		}
+		if fset.NArg() == 0 {
+			return usageError{errors.New("no version has been specified")}
+		}
+		return a.Remove(ctx, fset.Arg(0), opts)
+
+	case "reinstall":
 		if len(cmdArgs) == 0 {
 			return usageError{errors.New("no version has been specified")}
 		}
-		return app.Remove(ctx, cmdArgs[0])
+		return a.Reinstall(ctx, cmdArgs[0])
+
+	case "pin":
+		if len(cmdArgs) == 0 {
+			return a.ListPins()
+		}
+		return a.Pin(cmdArgs[0])
+
+	case "unpin":
+		if len(cmdArgs) == 0 {
+			return usageError{errors.New("no version has been specified")}
+		}
+		return a.Unpin(cmdArgs[0])
+
+	case "history":
+		return a.History()
+
+	case "which":
+		fset := flag.NewFlagSet("", flag.ContinueOnError)
+		fset.SetOutput(io.Discard)
+
+		var opts app.WhichOptions
+		fset.BoolVar(&opts.All, "all", false, "")
+		fset.BoolVar(&opts.JSON, "json", false, "")
+
+		if err := fset.Parse(cmdArgs); err != nil {
+			return withJSON(usageError{err}, opts.JSON)
+		}
+		return withJSON(a.Which(ctx, opts), opts.JSON)
+
+	case "files":
+		fset := flag.NewFlagSet("", flag.ContinueOnError)
+		fset.SetOutput(io.Discard)
+
+		var opts app.FilesOptions
+		fset.BoolVar(&opts.JSON, "json", false, "")
+
+		if err := fset.Parse(cmdArgs); err != nil {
+			return withJSON(usageError{err}, opts.JSON)
+		}
+		if fset.NArg() == 0 {
+			return withJSON(usageError{errors.New("no version has been specified")}, opts.JSON)
+		}
+		return withJSON(a.Files(ctx, fset.Arg(0), opts), opts.JSON)
+
+	case "env":
+		fset := flag.NewFlagSet("", flag.ContinueOnError)
+		fset.SetOutput(io.Discard)
+
+		var envFile string
+		fset.StringVar(&envFile, "env-file", "", "")
+
+		if err := fset.Parse(cmdArgs); err != nil {
+			return usageError{err}
+		}
+		if envFile != "" {
+			var w *outputFile
+			if w, err = createOutput(envFile); err != nil {
+				return err
+			}
+			a.Output = w
+			defer func() {
+				if cerr := w.Close(); err == nil && cerr != nil {
+					err = fmt.Errorf("writing %s: %w", envFile, cerr)
+				}
+			}()
+		}
+		return a.PrintEnv(ctx, fset.Arg(0))
+
+	case "shellenv":
+		var shell string
+		if len(cmdArgs) > 0 {
+			shell = cmdArgs[0]
+		}
+		return a.ShellEnv(shell)
+
+	case "bootstrap":
+		return a.Bootstrap(ctx)
+
+	case "config-dir":
+		return a.ConfigDir()
+
+	case "selfupdate":
+		fset := flag.NewFlagSet("", flag.ContinueOnError)
+		fset.SetOutput(io.Discard)
+
+		var checkOnly bool
+		fset.BoolVar(&checkOnly, "check", false, "")
+
+		if err := fset.Parse(cmdArgs); err != nil {
+			return usageError{err}
+		}
+		return a.SelfUpdate(ctx, version, checkOnly)
 
 	default:
 		return usageError{fmt.Errorf("unknown command %q", cmd)}
 	}
 }
 
+// outputFile is an -output file that remembers the first write error,
+// since the output is written with fmt.Fprintf, whose errors are ignored.
+type outputFile struct {
+	f   *os.File
+	err error
+}
+
+func createOutput(name string) (*outputFile, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &outputFile{f: f}, nil
+}
+
+func (o *outputFile) Write(p []byte) (int, error) {
+	if o.err != nil {
+		return 0, o.err
+	}
+	n, err := o.f.Write(p)
+	o.err = err
+	return n, err
+}
+
+// Close closes the file and reports the first write error, if any.
+func (o *outputFile) Close() error {
+	if err := o.f.Close(); o.err == nil {
+		o.err = err
+	}
+	return o.err
+}
+
+// parseAge parses a positive duration, which may also be given in days (e.g. 90d).
+func parseAge(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("malformed age %q", s)
+	}
+	return d, nil
+}
+
+// confirmFunc returns the [app.App.ConfirmNetwork] implementation that asks the user in the terminal.
+// If stdin is not a terminal (e.g. in CI), every network access is declined.
+func confirmFunc(stdin *os.File, stderr io.Writer) func(string) bool {
+	info, err := stdin.Stat()
+	terminal := err == nil && info.Mode()&os.ModeCharDevice != 0
+	r := bufio.NewReader(stdin)
+
+	return func(description string) bool {
+		if !terminal {
+			fmt.Fprintf(stderr, "goversion needs to %s, but stdin is not a terminal to confirm it\n", description)
+			return false
+		}
+		fmt.Fprintf(stderr, "goversion is about to %s. Continue? [y/N] ", description)
+		answer, _ := r.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true
+		}
+		return false
+	}
+}
+
+// newClient returns the HTTP client for go.dev requests,
+// which requires at least the given TLS version (e.g. 1.3) if it's not empty.
+func newClient(minTLS string) (*http.Client, error) {
+	if minTLS == "" {
+		return http.DefaultClient, nil
+	}
+
+	versions := map[string]uint16{
+		"1.2": tls.VersionTLS12,
+		"1.3": tls.VersionTLS13,
+	}
+	v, ok := versions[minTLS]
+	if !ok {
+		return nil, fmt.Errorf("-min-tls: unsupported TLS version %q (supported: 1.2, 1.3)", minTLS)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: v}
+	return &http.Client{Transport: transport}, nil
+}
+
 type usageError struct{ err error }
 
 func (e usageError) Error() string { return e.err.Error() }
 func (e usageError) Unwrap() error { return e.err }
+
+// jsonError is an error of a command run with -json, which is reported as a JSON object.
+type jsonError struct{ err error }
+
+func (e jsonError) Error() string { return e.err.Error() }
+func (e jsonError) Unwrap() error { return e.err }
+
+func withJSON(err error, enabled bool) error {
+	if err == nil || !enabled {
+		return err
+	}
+	return jsonError{err}
+}